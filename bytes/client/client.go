@@ -0,0 +1,251 @@
+// Package client is the Go client for the RESP protocol spoken by the
+// raw-tcp server, the cache servers and the proxy. A Client is safe for
+// concurrent use: it keeps a pool of connections, honours context deadlines
+// and cancellation, retries commands that fail on a broken connection, and
+// pipelines several commands in one round trip.
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/resp"
+)
+
+var (
+	// ErrNil is returned by the typed helpers for a null reply, e.g. GET of
+	// a missing key. An empty value is not nil.
+	ErrNil = errors.New("client: nil reply")
+
+	ErrClosed = errors.New("client: closed")
+)
+
+type Options struct {
+	Addr string
+
+	PoolSize    int           // most connections open at once; default 10
+	DialTimeout time.Duration // default 2s
+
+	// MaxRetries is how many times a command is resent after its connection
+	// broke (0 = never). A command that reached the server before the
+	// connection broke runs twice, so leave it 0 for clients sending
+	// non-idempotent commands such as INCR, or send those under NoRetry.
+	MaxRetries   int
+	RetryBackoff time.Duration // wait before the first retry, doubled after each; default 50ms
+}
+
+type Client struct {
+	opts  Options
+	slots chan struct{} // one token per connection in use
+
+	mu     sync.Mutex
+	idle   []*conn
+	closed bool
+}
+
+type conn struct {
+	nc net.Conn
+	r  *resp.Reader
+	w  *resp.Writer
+}
+
+func New(opts Options) *Client {
+	if opts.PoolSize <= 0 {
+		opts.PoolSize = 10
+	}
+	if opts.DialTimeout <= 0 {
+		opts.DialTimeout = 2 * time.Second
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = 50 * time.Millisecond
+	}
+	return &Client{opts: opts, slots: make(chan struct{}, opts.PoolSize)}
+}
+
+// Close closes the idle connections; ones in use are closed when their
+// command finishes.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	for _, cn := range c.idle {
+		cn.nc.Close()
+	}
+	c.idle = nil
+	return nil
+}
+
+// Do sends one command and returns its reply. Arguments may be strings,
+// []byte, integers, floats or bools. An error reply comes back both as the
+// value and as a resp.ReplyError; connection failures and cancellation
+// return only an error.
+func (c *Client) Do(ctx context.Context, args ...any) (resp.Value, error) {
+	return c.DoArgs(ctx, encodeArgs(args))
+}
+
+// DoArgs is Do for arguments that are already bytes.
+func (c *Client) DoArgs(ctx context.Context, args [][]byte) (resp.Value, error) {
+	replies, err := c.roundTrip(ctx, [][][]byte{args})
+	if err != nil {
+		return resp.Value{}, err
+	}
+	return replies[0], replies[0].Err()
+}
+
+type noRetryKey struct{}
+
+// NoRetry returns a context under which commands are never resent, whatever
+// MaxRetries says: for a client that mostly sends idempotent commands, the
+// odd INCR or CAS that must not run twice.
+func NoRetry(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noRetryKey{}, true)
+}
+
+// roundTrip writes cmds on one connection and reads a reply for each,
+// retrying on a fresh connection if that one broke.
+func (c *Client) roundTrip(ctx context.Context, cmds [][][]byte) ([]resp.Value, error) {
+	maxRetries := c.opts.MaxRetries
+	if ctx.Value(noRetryKey{}) != nil {
+		maxRetries = 0
+	}
+	for attempt := 0; ; attempt++ {
+		replies, err := c.try(ctx, cmds)
+		if err == nil || attempt >= maxRetries || !retryable(err) {
+			return replies, err
+		}
+		select {
+		case <-time.After(c.opts.RetryBackoff << attempt):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (c *Client) try(ctx context.Context, cmds [][][]byte) ([]resp.Value, error) {
+	cn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The deadline bounds blocked reads and writes; cancellation cuts them
+	// short by moving the deadline to the past.
+	dl, _ := ctx.Deadline()
+	cn.nc.SetDeadline(dl)
+	stop := context.AfterFunc(ctx, func() { cn.nc.SetDeadline(time.Unix(1, 0)) })
+
+	replies, err := cn.roundTrip(cmds)
+	interrupted := !stop()
+	if err != nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	// A connection that failed or was interrupted may be mid-reply.
+	c.put(cn, err != nil || interrupted)
+	return replies, err
+}
+
+func (cn *conn) roundTrip(cmds [][][]byte) ([]resp.Value, error) {
+	for _, args := range cmds {
+		cn.w.WriteCommand(args...)
+	}
+	if err := cn.w.Flush(); err != nil {
+		return nil, err
+	}
+	replies := make([]resp.Value, len(cmds))
+	for i := range replies {
+		v, err := cn.r.ReadValue()
+		if err != nil {
+			return nil, err
+		}
+		replies[i] = v
+	}
+	return replies, nil
+}
+
+// get takes an idle connection or dials a new one, waiting for a free slot
+// if PoolSize connections are in use.
+func (c *Client) get(ctx context.Context) (*conn, error) {
+	select {
+	case c.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		<-c.slots
+		return nil, ErrClosed
+	}
+	if n := len(c.idle); n > 0 {
+		cn := c.idle[n-1]
+		c.idle = c.idle[:n-1]
+		c.mu.Unlock()
+		return cn, nil
+	}
+	c.mu.Unlock()
+
+	d := net.Dialer{Timeout: c.opts.DialTimeout}
+	nc, err := d.DialContext(ctx, "tcp", c.opts.Addr)
+	if err != nil {
+		<-c.slots
+		return nil, err
+	}
+	return &conn{nc: nc, r: resp.NewReader(nc), w: resp.NewWriter(nc)}, nil
+}
+
+// put gives cn back to the pool, or closes it if it is broken.
+func (c *Client) put(cn *conn, broken bool) {
+	c.mu.Lock()
+	if broken || c.closed {
+		cn.nc.Close()
+	} else {
+		c.idle = append(c.idle, cn)
+	}
+	c.mu.Unlock()
+	<-c.slots
+}
+
+// retryable reports whether err means the connection broke, as opposed to
+// a cancelled context, a closed client or a server that speaks no RESP.
+func retryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var ne net.Error
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.As(err, &ne)
+}
+
+func encodeArgs(args []any) [][]byte {
+	out := make([][]byte, len(args))
+	for i, a := range args {
+		switch a := a.(type) {
+		case []byte:
+			out[i] = a
+		case string:
+			out[i] = []byte(a)
+		case int:
+			out[i] = strconv.AppendInt(nil, int64(a), 10)
+		case int64:
+			out[i] = strconv.AppendInt(nil, a, 10)
+		case uint64:
+			out[i] = strconv.AppendUint(nil, a, 10)
+		case float64:
+			out[i] = strconv.AppendFloat(nil, a, 'f', -1, 64)
+		case bool:
+			if a {
+				out[i] = []byte("1")
+			} else {
+				out[i] = []byte("0")
+			}
+		default:
+			out[i] = fmt.Append(nil, a)
+		}
+	}
+	return out
+}
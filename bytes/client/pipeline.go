@@ -0,0 +1,43 @@
+package client
+
+import (
+	"context"
+
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/resp"
+)
+
+// Pipeline queues commands and sends them in one write, reading all their
+// replies after: one round trip instead of one per command. It is not a
+// transaction; other clients' commands may run in between (use MULTI/EXEC
+// for that). A Pipeline is not safe for concurrent use.
+type Pipeline struct {
+	c    *Client
+	cmds [][][]byte
+}
+
+func (c *Client) Pipeline() *Pipeline {
+	return &Pipeline{c: c}
+}
+
+// Do queues a command; it takes the same arguments as Client.Do.
+func (p *Pipeline) Do(args ...any) {
+	p.cmds = append(p.cmds, encodeArgs(args))
+}
+
+func (p *Pipeline) DoArgs(args [][]byte) {
+	p.cmds = append(p.cmds, args)
+}
+
+func (p *Pipeline) Len() int { return len(p.cmds) }
+
+// Exec sends the queued commands and returns their replies in order, then
+// empties the queue. Error replies are values (see resp.Value.Err); err is
+// set only if the round trip itself failed.
+func (p *Pipeline) Exec(ctx context.Context) ([]resp.Value, error) {
+	cmds := p.cmds
+	p.cmds = nil
+	if len(cmds) == 0 {
+		return nil, nil
+	}
+	return p.c.roundTrip(ctx, cmds)
+}
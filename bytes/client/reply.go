@@ -0,0 +1,163 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/resp"
+)
+
+// The typed helpers wrap Do's result, e.g. client.Int(c.Do(ctx, "INCR", k)).
+// They pass errors through, turn a null into ErrNil, and reject replies of
+// the wrong type.
+
+// Bytes returns a string reply. A null is ErrNil; an empty value is a
+// non-nil empty slice.
+func Bytes(v resp.Value, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	switch v.Type {
+	case resp.Null:
+		return nil, ErrNil
+	case resp.BulkString, resp.SimpleString, resp.Verbatim:
+		if v.Str == nil {
+			return []byte{}, nil
+		}
+		return v.Str, nil
+	}
+	return nil, unexpected(v, "string")
+}
+
+func String(v resp.Value, err error) (string, error) {
+	b, err := Bytes(v, err)
+	return string(b), err
+}
+
+func Int(v resp.Value, err error) (int64, error) {
+	if err != nil {
+		return 0, err
+	}
+	switch v.Type {
+	case resp.Null:
+		return 0, ErrNil
+	case resp.Integer, resp.Boolean:
+		return v.Int, nil
+	}
+	return 0, unexpected(v, "integer")
+}
+
+// BytesSlice returns an array of strings; a null element is nil, an empty
+// one is a non-nil empty slice.
+func BytesSlice(v resp.Value, err error) ([][]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	switch v.Type {
+	case resp.Null:
+		return nil, ErrNil
+	case resp.Array, resp.Set, resp.Push:
+	default:
+		return nil, unexpected(v, "array")
+	}
+	out := make([][]byte, len(v.Elems))
+	for i, e := range v.Elems {
+		b, err := Bytes(e, nil)
+		if err != nil && err != ErrNil {
+			return nil, err
+		}
+		out[i] = b
+	}
+	return out, nil
+}
+
+func Strings(v resp.Value, err error) ([]string, error) {
+	bs, err := BytesSlice(v, err)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, len(bs))
+	for i, b := range bs {
+		out[i] = string(b)
+	}
+	return out, nil
+}
+
+func unexpected(v resp.Value, want string) error {
+	return fmt.Errorf("client: got %q reply, want %s", byte(v.Type), want)
+}
+
+// Shorthands for the common commands; anything else goes through Do.
+
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.Do(ctx, "PING")
+	return err
+}
+
+// Get returns ErrNil for a missing key.
+func (c *Client) Get(ctx context.Context, key string) ([]byte, error) {
+	return Bytes(c.Do(ctx, "GET", key))
+}
+
+func (c *Client) Set(ctx context.Context, key string, value []byte) error {
+	_, err := c.Do(ctx, "SET", key, value)
+	return err
+}
+
+// Del reports whether the key existed.
+func (c *Client) Del(ctx context.Context, key string) (bool, error) {
+	n, err := Int(c.Do(ctx, "DEL", key))
+	return n > 0, err
+}
+
+// MGet returns one value per key, nil for missing ones.
+func (c *Client) MGet(ctx context.Context, keys ...string) ([][]byte, error) {
+	args := make([]any, 0, 1+len(keys))
+	args = append(args, "MGET")
+	for _, k := range keys {
+		args = append(args, k)
+	}
+	return BytesSlice(c.Do(ctx, args...))
+}
+
+// MSet writes key/value pairs: kv = k1, v1, k2, v2, ...
+func (c *Client) MSet(ctx context.Context, kv ...[]byte) error {
+	_, err := c.DoArgs(ctx, append([][]byte{[]byte("MSET")}, kv...))
+	return err
+}
+
+func (c *Client) Keys(ctx context.Context) ([]string, error) {
+	return Strings(c.Do(ctx, "KEYS"))
+}
+
+// Scan runs one SCAN step: pass 0 as the first cursor and the returned one
+// after that, until it is 0 again. match "" and count 0 use the server's
+// defaults. A step may return no keys without being the last. Keys present
+// throughout the iteration are returned exactly once; through the proxy,
+// only while its servers don't change.
+func (c *Client) Scan(ctx context.Context, cursor uint64, match string, count int) (next uint64, keys []string, err error) {
+	args := []any{"SCAN", cursor}
+	if match != "" {
+		args = append(args, "MATCH", match)
+	}
+	if count > 0 {
+		args = append(args, "COUNT", count)
+	}
+	v, err := c.Do(ctx, args...)
+	if err != nil {
+		return 0, nil, err
+	}
+	if v.Type != resp.Array || len(v.Elems) != 2 {
+		return 0, nil, unexpected(v, "SCAN reply")
+	}
+	cur, err := String(v.Elems[0], nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	if next, err = strconv.ParseUint(cur, 10, 64); err != nil {
+		return 0, nil, fmt.Errorf("client: bad SCAN cursor %q", cur)
+	}
+	keys, err = Strings(v.Elems[1], nil)
+	return next, keys, err
+}
@@ -0,0 +1,156 @@
+package command
+
+import "strings"
+
+// Handlers every binary serves the same way.
+
+func Ping(c *Conn, args [][]byte) {
+	if len(args) > 1 {
+		c.W.WriteBulk(args[1])
+		return
+	}
+	c.W.WriteSimple("PONG")
+}
+
+func Echo(c *Conn, args [][]byte) {
+	c.W.WriteBulk(args[1])
+}
+
+func Quit(c *Conn, args [][]byte) {
+	c.W.WriteSimple("BYE")
+	c.Quit = true
+}
+
+// OK acknowledges commands clients send on connect (SELECT, CLIENT
+// SETNAME, ...): there is one database and no client bookkeeping.
+func OK(c *Conn, args [][]byte) {
+	c.W.WriteSimple("OK")
+}
+
+// Hello switches the connection to the requested protocol version and
+// replies with info, given as name, value pairs, plus "proto".
+func Hello(info ...string) Handler {
+	return func(c *Conn, args [][]byte) {
+		if len(args) > 1 {
+			switch string(args[1]) {
+			case "2", "3":
+				c.W.SetProtocol(int(args[1][0] - '0'))
+			default:
+				c.W.WriteError("NOPROTO unsupported protocol version")
+				return
+			}
+		}
+		c.W.WriteMap(len(info)/2 + 1)
+		for _, s := range info {
+			c.W.WriteBulkString(s)
+		}
+		c.W.WriteBulkString("proto")
+		c.W.WriteInt(int64(c.W.Protocol()))
+	}
+}
+
+// Help lists the commands t serves.
+func Help(t *Table) Handler {
+	return func(c *Conn, args [][]byte) {
+		c.W.WriteSimple("Commands: " + strings.Join(t.Names(), " "))
+	}
+}
+
+// Store is what the basic string commands need; the raw-tcp server's
+// kv.Store and the cache server's store both have it.
+type Store interface {
+	Set(k string, v []byte)
+	Get(k string) ([]byte, bool)
+	Del(k string) bool
+	MGet(keys []string) (vals [][]byte, found []bool)
+	MSet(kv [][]byte)
+	MSetNX(kv [][]byte) bool
+	Keys() []string
+	Scan(cursor uint64, match string, count int) (next uint64, keys []string)
+}
+
+// StoreCommands are the commands HandleStore serves: all that a cache
+// server knows, so all the proxy forwards.
+var StoreCommands = []string{"GET", "SET", "DEL", "MGET", "MSET", "MSETNX", "KEYS", "SCAN"}
+
+// HandleStore serves StoreCommands from st.
+// SET takes no options here; a binary whose store supports them replaces
+// the handler.
+func HandleStore(t *Table, st Store) {
+	t.Handle("GET", func(c *Conn, args [][]byte) {
+		if v, ok := st.Get(string(args[1])); ok {
+			c.W.WriteBulk(v)
+		} else {
+			c.W.WriteNull()
+		}
+	})
+	t.Handle("SET", func(c *Conn, args [][]byte) {
+		if len(args) > 3 {
+			c.W.WriteError("ERR syntax error")
+			return
+		}
+		st.Set(string(args[1]), args[2])
+		c.W.WriteSimple("OK")
+	})
+	t.Handle("DEL", func(c *Conn, args [][]byte) {
+		if st.Del(string(args[1])) {
+			c.W.WriteInt(1)
+		} else {
+			c.W.WriteInt(0)
+		}
+	})
+	t.Handle("MGET", func(c *Conn, args [][]byte) {
+		vals, found := st.MGet(KeyStrings(args[1:]))
+		c.W.WriteArray(len(vals))
+		for i, v := range vals {
+			if found[i] {
+				c.W.WriteBulk(v)
+			} else {
+				c.W.WriteNull()
+			}
+		}
+	})
+	t.Handle("MSET", func(c *Conn, args [][]byte) {
+		if len(args)%2 == 0 {
+			c.UsageError()
+			return
+		}
+		st.MSet(args[1:])
+		c.W.WriteSimple("OK")
+	})
+	t.Handle("MSETNX", func(c *Conn, args [][]byte) {
+		if len(args)%2 == 0 {
+			c.UsageError()
+			return
+		}
+		if st.MSetNX(args[1:]) {
+			c.W.WriteInt(1)
+		} else {
+			c.W.WriteInt(0)
+		}
+	})
+	t.Handle("KEYS", func(c *Conn, args [][]byte) {
+		keys := st.Keys()
+		c.W.WriteArray(len(keys))
+		for _, k := range keys {
+			c.W.WriteBulkString(k)
+		}
+	})
+	t.Handle("SCAN", func(c *Conn, args [][]byte) {
+		sa, msg := ParseScan(args)
+		if msg != "" {
+			c.W.WriteError(msg)
+			return
+		}
+		next, keys := st.Scan(sa.Cursor, sa.Match, sa.Count)
+		WriteScan(c.W, next, keys)
+	})
+}
+
+func KeyStrings(args [][]byte) []string {
+	keys := make([]string, len(args))
+	for i, a := range args {
+		keys[i] = string(a)
+	}
+	return keys
+}
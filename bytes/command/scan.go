@@ -0,0 +1,60 @@
+package command
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/resp"
+)
+
+// DefaultScanCount is SCAN's COUNT when the client gives none, as in Redis.
+const DefaultScanCount = 10
+
+// ScanArgs are the arguments of SCAN cursor [MATCH pattern] [COUNT count].
+type ScanArgs struct {
+	Cursor uint64
+	Match  string // "" = every key
+	Count  int
+}
+
+// ParseScan parses SCAN's arguments, or returns the error reply.
+func ParseScan(args [][]byte) (ScanArgs, string) {
+	sa := ScanArgs{Count: DefaultScanCount}
+	cursor, err := strconv.ParseUint(string(args[1]), 10, 64)
+	if err != nil {
+		return sa, "ERR invalid cursor"
+	}
+	sa.Cursor = cursor
+	for i := 2; i < len(args); i += 2 {
+		if i+1 == len(args) {
+			return sa, "ERR syntax error"
+		}
+		switch strings.ToUpper(string(args[i])) {
+		case "MATCH":
+			sa.Match = string(args[i+1])
+		case "COUNT":
+			n, err := strconv.Atoi(string(args[i+1]))
+			if err != nil {
+				return sa, "ERR value is not an integer or out of range"
+			}
+			if n < 1 {
+				return sa, "ERR syntax error"
+			}
+			sa.Count = n
+		default:
+			return sa, "ERR syntax error"
+		}
+	}
+	return sa, ""
+}
+
+// WriteScan writes a SCAN reply: the next cursor as a bulk string, then the
+// keys.
+func WriteScan(w *resp.Writer, next uint64, keys []string) {
+	w.WriteArray(2)
+	w.WriteBulkString(strconv.FormatUint(next, 10))
+	w.WriteArray(len(keys))
+	for _, k := range keys {
+		w.WriteBulkString(k)
+	}
+}
@@ -0,0 +1,139 @@
+// Package command is the command table shared by the raw-tcp server, the
+// cache servers and the proxy. Every command is described once here (arity,
+// flags, key positions, usage); each binary then gives a Table the handlers
+// for the commands it serves, and the Table checks arity, dispatches and
+// answers COMMAND.
+package command
+
+import (
+	"sort"
+	"strings"
+)
+
+// Flag describes how a command behaves, as reported by COMMAND INFO.
+type Flag uint8
+
+const (
+	Write    Flag = 1 << iota // may modify data
+	ReadOnly                  // only reads data
+	Admin                     // changes the server or the cluster, not data
+	Fast                      // constant time
+	NoMulti                   // runs immediately, even inside MULTI
+)
+
+var flagNames = []struct {
+	f    Flag
+	name string
+}{
+	{Write, "write"},
+	{ReadOnly, "readonly"},
+	{Admin, "admin"},
+	{Fast, "fast"},
+	{NoMulti, "no_multi"},
+}
+
+func (f Flag) Names() []string {
+	var names []string
+	for _, fn := range flagNames {
+		if f&fn.f != 0 {
+			names = append(names, fn.name)
+		}
+	}
+	return names
+}
+
+// Spec describes a command.
+type Spec struct {
+	Name string
+
+	// Arity counts the arguments including the name, like Redis: N means
+	// exactly N, -N means at least N.
+	Arity int
+	Flags Flag
+
+	// FirstKey, LastKey and Step locate the keys in the arguments (LastKey
+	// -1 = the last argument); all zero for commands without keys. The
+	// proxy routes on them.
+	FirstKey, LastKey, Step int
+
+	Usage string
+}
+
+func (s Spec) Has(f Flag) bool { return s.Flags&f != 0 }
+
+// ArityOK reports whether n arguments (including the name) fit Arity.
+func (s Spec) ArityOK(n int) bool {
+	if s.Arity < 0 {
+		return n >= -s.Arity
+	}
+	return n == s.Arity
+}
+
+// SingleKey reports whether the command's only key is its first argument.
+func (s Spec) SingleKey() bool { return s.FirstKey == 1 && s.LastKey == 1 }
+
+// specs lists every command any binary serves. A new command is added
+// here once, then handled by each binary that serves it.
+var specs = []Spec{
+	// connection
+	{Name: "PING", Arity: -1, Flags: Fast, Usage: "PING [message]"},
+	{Name: "ECHO", Arity: 2, Flags: Fast, Usage: "ECHO message"},
+	{Name: "QUIT", Arity: -1, Flags: Fast | NoMulti, Usage: "QUIT"},
+	{Name: "HELLO", Arity: -1, Flags: Fast | NoMulti, Usage: "HELLO [2|3]"},
+	{Name: "SELECT", Arity: 2, Flags: Fast, Usage: "SELECT index"},
+	{Name: "CLIENT", Arity: -2, Usage: "CLIENT subcommand [arg ...]"},
+	{Name: "COMMAND", Arity: -1, Usage: "COMMAND [COUNT|LIST|INFO [name ...]|DOCS [name ...]]"},
+	{Name: "HELP", Arity: 1, Usage: "HELP"},
+	{Name: "WHOAMI", Arity: 1, Flags: Fast, Usage: "WHOAMI"},
+
+	// strings
+	{Name: "GET", Arity: 2, Flags: ReadOnly | Fast, FirstKey: 1, LastKey: 1, Step: 1, Usage: "GET key"},
+	{Name: "SET", Arity: -3, Flags: Write, FirstKey: 1, LastKey: 1, Step: 1, Usage: "SET key value [NX|XX] [GET]"},
+	{Name: "GETVER", Arity: 2, Flags: ReadOnly | Fast, FirstKey: 1, LastKey: 1, Step: 1, Usage: "GETVER key"},
+	{Name: "CAS", Arity: 4, Flags: Write | Fast, FirstKey: 1, LastKey: 1, Step: 1, Usage: "CAS key version value"},
+	{Name: "DEL", Arity: 2, Flags: Write, FirstKey: 1, LastKey: 1, Step: 1, Usage: "DEL key"},
+	{Name: "INCR", Arity: 2, Flags: Write | Fast, FirstKey: 1, LastKey: 1, Step: 1, Usage: "INCR key"},
+	{Name: "DECR", Arity: 2, Flags: Write | Fast, FirstKey: 1, LastKey: 1, Step: 1, Usage: "DECR key"},
+	{Name: "INCRBY", Arity: 3, Flags: Write | Fast, FirstKey: 1, LastKey: 1, Step: 1, Usage: "INCRBY key increment"},
+	{Name: "DECRBY", Arity: 3, Flags: Write | Fast, FirstKey: 1, LastKey: 1, Step: 1, Usage: "DECRBY key decrement"},
+	{Name: "INCRBYFLOAT", Arity: 3, Flags: Write | Fast, FirstKey: 1, LastKey: 1, Step: 1, Usage: "INCRBYFLOAT key increment"},
+	{Name: "MGET", Arity: -2, Flags: ReadOnly | Fast, FirstKey: 1, LastKey: -1, Step: 1, Usage: "MGET key [key ...]"},
+	{Name: "MSET", Arity: -3, Flags: Write, FirstKey: 1, LastKey: -1, Step: 2, Usage: "MSET key value [key value ...]"},
+	{Name: "MSETNX", Arity: -3, Flags: Write, FirstKey: 1, LastKey: -1, Step: 2, Usage: "MSETNX key value [key value ...]"},
+	{Name: "KEYS", Arity: -1, Flags: ReadOnly, Usage: "KEYS"},
+	{Name: "SCAN", Arity: -2, Flags: ReadOnly, Usage: "SCAN cursor [MATCH pattern] [COUNT count]"},
+
+	// transactions
+	{Name: "MULTI", Arity: 1, Flags: Fast | NoMulti, Usage: "MULTI"},
+	{Name: "EXEC", Arity: 1, Flags: NoMulti, Usage: "EXEC"},
+	{Name: "DISCARD", Arity: 1, Flags: Fast | NoMulti, Usage: "DISCARD"},
+	{Name: "WATCH", Arity: -2, Flags: Fast | NoMulti, FirstKey: 1, LastKey: -1, Step: 1, Usage: "WATCH key [key ...]"},
+	{Name: "UNWATCH", Arity: 1, Flags: Fast | NoMulti, Usage: "UNWATCH"},
+
+	// cluster (proxy)
+	{Name: "ADD_SERVER", Arity: 2, Flags: Admin, Usage: "ADD_SERVER host:port"},
+	{Name: "REMOVE_SERVER", Arity: 2, Flags: Admin, Usage: "REMOVE_SERVER host:port"},
+	{Name: "SERVERS", Arity: 1, Flags: Fast, Usage: "SERVERS"},
+	{Name: "ROUTE", Arity: 2, Flags: Fast, Usage: "ROUTE key"},
+}
+
+var byName = func() map[string]Spec {
+	m := make(map[string]Spec, len(specs))
+	for _, s := range specs {
+		m[s.Name] = s
+	}
+	return m
+}()
+
+// Lookup returns the spec of a command, in any case.
+func Lookup(name string) (Spec, bool) {
+	s, ok := byName[strings.ToUpper(name)]
+	return s, ok
+}
+
+// Specs returns every known command, sorted by name.
+func Specs() []Spec {
+	out := append([]Spec(nil), specs...)
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
@@ -0,0 +1,164 @@
+package command
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/resp"
+)
+
+// Conn is one client connection as handlers see it.
+type Conn struct {
+	W *resp.Writer
+
+	// Quit is set by a handler to close the connection after the reply.
+	Quit bool
+	// Data is the binary's own per-connection state, e.g. the raw-tcp
+	// server's transaction.
+	Data any
+
+	spec Spec // of the command being run
+}
+
+// UsageError replies with the running command's usage, for arguments that
+// fit its arity but not its syntax.
+func (c *Conn) UsageError() {
+	c.W.WriteError("ERR usage: " + c.spec.Usage)
+}
+
+// Handler runs one command whose arity was already checked; args[0] is the
+// name as the client sent it.
+type Handler func(c *Conn, args [][]byte)
+
+type entry struct {
+	spec    Spec
+	handler Handler
+}
+
+// Table maps the commands a binary serves to their handlers. It is built
+// at startup and read-only after, so connections share it.
+type Table struct {
+	cmds map[string]entry
+}
+
+// NewTable returns a table serving COMMAND only.
+func NewTable() *Table {
+	t := &Table{cmds: make(map[string]entry)}
+	t.Handle("COMMAND", t.command)
+	return t
+}
+
+// Handle serves the named command with h, replacing any earlier handler.
+// It panics if the command has no Spec: add one to specs first.
+func (t *Table) Handle(name string, h Handler) {
+	spec, ok := Lookup(name)
+	if !ok {
+		panic("command: no spec for " + name)
+	}
+	t.cmds[spec.Name] = entry{spec: spec, handler: h}
+}
+
+// Lookup returns the spec of a command the table serves.
+func (t *Table) Lookup(name []byte) (Spec, bool) {
+	e, ok := t.cmds[strings.ToUpper(string(name))]
+	return e.spec, ok
+}
+
+// Check returns the error reply for a command that is unknown or has the
+// wrong number of arguments, or "" if it can run.
+func (t *Table) Check(args [][]byte) string {
+	spec, ok := t.Lookup(args[0])
+	if !ok {
+		return "ERR unknown command '" + string(args[0]) + "'"
+	}
+	if !spec.ArityOK(len(args)) {
+		return "ERR usage: " + spec.Usage
+	}
+	return ""
+}
+
+// Dispatch runs one command, or replies with why it can't run.
+func (t *Table) Dispatch(c *Conn, args [][]byte) {
+	if msg := t.Check(args); msg != "" {
+		c.W.WriteError(msg)
+		return
+	}
+	e := t.cmds[strings.ToUpper(string(args[0]))]
+	c.spec = e.spec
+	e.handler(c, args)
+}
+
+// Names returns the served commands, sorted.
+func (t *Table) Names() []string {
+	names := make([]string, 0, len(t.cmds))
+	for name := range t.cmds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// command answers COMMAND with the served commands in Redis' format, so
+// redis-cli and client libraries can introspect them.
+func (t *Table) command(c *Conn, args [][]byte) {
+	sub := ""
+	if len(args) > 1 {
+		sub = strings.ToUpper(string(args[1]))
+	}
+	switch sub {
+	case "":
+		names := t.Names()
+		c.W.WriteArray(len(names))
+		for _, name := range names {
+			writeInfo(c.W, t.cmds[name].spec)
+		}
+
+	case "COUNT":
+		c.W.WriteInt(int64(len(t.cmds)))
+
+	case "LIST":
+		names := t.Names()
+		c.W.WriteArray(len(names))
+		for _, name := range names {
+			c.W.WriteBulkString(strings.ToLower(name))
+		}
+
+	case "INFO":
+		c.W.WriteArray(len(args) - 2)
+		for _, name := range args[2:] {
+			if spec, ok := t.Lookup(name); ok {
+				writeInfo(c.W, spec)
+			} else {
+				c.W.WriteNullArray()
+			}
+		}
+
+	case "DOCS":
+		// No docs beyond the usage in COMMAND INFO; redis-cli asks on connect.
+		c.W.WriteMap(0)
+
+	default:
+		c.UsageError()
+	}
+}
+
+// writeInfo writes one COMMAND INFO entry: name, arity, flags, first key,
+// last key, step, then the ACL categories, tips, key specs and subcommands
+// of Redis 7, which are empty here.
+func writeInfo(w *resp.Writer, s Spec) {
+	w.WriteArray(10)
+	w.WriteBulkString(strings.ToLower(s.Name))
+	w.WriteInt(int64(s.Arity))
+	flags := s.Flags.Names()
+	w.WriteSet(len(flags))
+	for _, f := range flags {
+		w.WriteSimple(f)
+	}
+	w.WriteInt(int64(s.FirstKey))
+	w.WriteInt(int64(s.LastKey))
+	w.WriteInt(int64(s.Step))
+	w.WriteSet(0)
+	w.WriteArray(0)
+	w.WriteArray(0)
+	w.WriteArray(0)
+}
@@ -1,11 +1,13 @@
 package main
 
 import (
-	"bufio"
+	"context"
 	"fmt"
-	"net"
 	"strings"
 	"time"
+
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/client"
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/resp"
 )
 
 const proxyAddr = "127.0.0.1:6380"
@@ -20,7 +22,12 @@ func main() {
 	fmt.Println()
 
 	// Connect to proxy
-	conn, err := net.DialTimeout("tcp", proxyAddr, 2*time.Second)
+	cl := client.New(client.Options{Addr: proxyAddr})
+	defer cl.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	err := cl.Ping(ctx)
+	cancel()
 	if err != nil {
 		fmt.Printf("❌ Cannot connect to proxy at %s\n", proxyAddr)
 		fmt.Println("   Make sure to start the proxy and servers first!")
@@ -32,43 +39,24 @@ func main() {
 		fmt.Println("   Terminal 5: go run ./demo")
 		return
 	}
-	defer conn.Close()
-
-	r := bufio.NewReader(conn)
-	w := bufio.NewWriter(conn)
 
-	// Read greeting
-	r.ReadString('\n')
-
-	send := func(cmd string) string {
-		w.WriteString(cmd + "\n")
-		w.Flush()
-		line, _ := r.ReadString('\n')
-		line = strings.TrimSpace(line)
-
-		// Handle bulk strings
-		if strings.HasPrefix(line, "$") && !strings.HasPrefix(line, "$-1") {
-			value, _ := r.ReadString('\n')
-			return strings.TrimSpace(value)
+	// send runs one command and renders its reply; a missing key or an
+	// error is "".
+	send := func(args ...any) string {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		reply, err := cl.Do(ctx, args...)
+		if err != nil || reply.IsNull() {
+			return ""
 		}
-		// Handle arrays
-		if strings.HasPrefix(line, "*") {
-			var count int
-			fmt.Sscanf(line, "*%d", &count)
-			var items []string
-			for i := 0; i < count; i++ {
-				item, _ := r.ReadString('\n')
-				item = strings.TrimSpace(item)
-				if strings.HasPrefix(item, "+") {
-					items = append(items, item[1:])
-				}
+		if reply.Type == resp.Array {
+			items := make([]string, len(reply.Elems))
+			for i, item := range reply.Elems {
+				items[i] = item.String()
 			}
 			return strings.Join(items, ", ")
 		}
-		if strings.HasPrefix(line, "+") {
-			return line[1:]
-		}
-		return line
+		return reply.String()
 	}
 
 	testKeys := []string{
@@ -90,7 +78,7 @@ func main() {
 		fmt.Printf("Active servers: [%s]\n\n", servers)
 		fmt.Printf("%-20s → %s\n", "KEY", "ROUTED TO")
 		for _, key := range testKeys {
-			server := send("ROUTE " + key)
+			server := send("ROUTE", key)
 			fmt.Printf("%-20s → %s\n", key, server)
 		}
 		fmt.Println()
@@ -101,8 +89,8 @@ func main() {
 	// ─────────────────────────────────────────────────────────────────────────
 	fmt.Println()
 	fmt.Println("📌 STEP 1: Adding initial servers (cache-A and cache-B)")
-	send("ADD_SERVER 127.0.0.1:6381") // cache-A
-	send("ADD_SERVER 127.0.0.1:6382") // cache-B
+	send("ADD_SERVER", "127.0.0.1:6381") // cache-A
+	send("ADD_SERVER", "127.0.0.1:6382") // cache-B
 
 	showRouting("Initial routing with 2 servers")
 
@@ -112,8 +100,8 @@ func main() {
 	fmt.Println("📌 STEP 2: Storing data through the proxy")
 	for _, key := range testKeys {
 		value := fmt.Sprintf("value-for-%s", key)
-		send(fmt.Sprintf("SET %s %s", key, value))
-		server := send("ROUTE " + key)
+		send("SET", key, value)
+		server := send("ROUTE", key)
 		fmt.Printf("   SET %-20s → stored on %s\n", key, server)
 	}
 	fmt.Println()
@@ -123,7 +111,7 @@ func main() {
 	// ─────────────────────────────────────────────────────────────────────────
 	fmt.Println("📌 STEP 3: Adding a new server (cache-C)")
 	fmt.Println("   Watch how some keys now route to the new server!")
-	send("ADD_SERVER 127.0.0.1:6383") // cache-C
+	send("ADD_SERVER", "127.0.0.1:6383") // cache-C
 
 	showRouting("Routing after adding cache-C (127.0.0.1:6383)")
 
@@ -135,9 +123,9 @@ func main() {
 	fmt.Println()
 	hits, misses := 0, 0
 	for _, key := range testKeys {
-		result := send("GET " + key)
-		server := send("ROUTE " + key)
-		if result == "-1" || result == "" {
+		result := send("GET", key)
+		server := send("ROUTE", key)
+		if result == "" {
 			fmt.Printf("   ❌ MISS: %-20s on %s (data was on another server)\n", key, server)
 			misses++
 		} else {
@@ -153,7 +141,7 @@ func main() {
 	// ─────────────────────────────────────────────────────────────────────────
 	fmt.Println("📌 STEP 5: Removing a server (cache-B)")
 	fmt.Println("   Some keys that were on cache-B will now route elsewhere!")
-	send("REMOVE_SERVER 127.0.0.1:6382")
+	send("REMOVE_SERVER", "127.0.0.1:6382")
 
 	showRouting("Routing after removing cache-B (127.0.0.1:6382)")
 
@@ -163,7 +151,7 @@ func main() {
 	fmt.Println("📌 STEP 6: Re-storing all data with current topology")
 	for _, key := range testKeys {
 		value := fmt.Sprintf("value-for-%s", key)
-		send(fmt.Sprintf("SET %s %s", key, value))
+		send("SET", key, value)
 	}
 	fmt.Println("   All keys re-stored.")
 	fmt.Println()
@@ -171,9 +159,9 @@ func main() {
 	fmt.Println("📌 STEP 7: Verifying all keys are now accessible")
 	hits, misses = 0, 0
 	for _, key := range testKeys {
-		result := send("GET " + key)
-		server := send("ROUTE " + key)
-		if result == "-1" || result == "" {
+		result := send("GET", key)
+		server := send("ROUTE", key)
+		if result == "" {
 			fmt.Printf("   ❌ MISS: %-20s on %s\n", key, server)
 			misses++
 		} else {
@@ -205,6 +193,6 @@ func main() {
 	fmt.Println("  4. Virtual nodes (replicas) help distribute keys more evenly")
 	fmt.Println("     across servers")
 	fmt.Println()
-	fmt.Println("Try the interactive demo with: nc 127.0.0.1 6380")
+	fmt.Println("Try the interactive demo with: redis-cli -p 6380 (or nc 127.0.0.1 6380)")
 	fmt.Println()
 }
@@ -1,16 +1,23 @@
 package main
 
 import (
-	"bufio"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"hash/fnv"
 	"log"
+	"math"
 	"net"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/client"
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/command"
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/resp"
 )
 
 // ──────────────────────────────────────────────────────────────────────────────
@@ -108,45 +115,39 @@ func hash32(s string) uint32 {
 // Connection Pool
 // ──────────────────────────────────────────────────────────────────────────────
 
+// ConnPool holds one client per cache server; each client pools its own
+// connections, so concurrent proxy clients don't queue behind each other.
 type ConnPool struct {
-	mu    sync.Mutex
-	conns map[string]net.Conn
+	mu      sync.Mutex
+	clients map[string]*client.Client
 }
 
 func NewConnPool() *ConnPool {
-	return &ConnPool{conns: make(map[string]net.Conn)}
+	return &ConnPool{clients: make(map[string]*client.Client)}
 }
 
-func (p *ConnPool) Get(addr string) (net.Conn, *bufio.Reader, *bufio.Writer, error) {
+func (p *ConnPool) Get(addr string) *client.Client {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	if conn, ok := p.conns[addr]; ok {
-		return conn, bufio.NewReader(conn), bufio.NewWriter(conn), nil
+	cl, ok := p.clients[addr]
+	if !ok {
+		// Retry once on a broken connection, e.g. after the server
+		// restarted. Commands that are not safe to resend go out under
+		// client.NoRetry (see retrySafe).
+		cl = client.New(client.Options{Addr: addr, MaxRetries: 1})
+		p.clients[addr] = cl
 	}
-
-	conn, err := net.DialTimeout("tcp", addr, 2*time.Second)
-	if err != nil {
-		return nil, nil, nil, err
-	}
-
-	r := bufio.NewReader(conn)
-	w := bufio.NewWriter(conn)
-
-	// read the greeting
-	_, _ = r.ReadString('\n')
-
-	p.conns[addr] = conn
-	return conn, r, w, nil
+	return cl
 }
 
 func (p *ConnPool) Remove(addr string) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	if conn, ok := p.conns[addr]; ok {
-		conn.Close()
-		delete(p.conns, addr)
+	if cl, ok := p.clients[addr]; ok {
+		cl.Close()
+		delete(p.clients, addr)
 	}
 }
 
@@ -154,9 +155,9 @@ func (p *ConnPool) Close() {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	for addr, conn := range p.conns {
-		conn.Close()
-		delete(p.conns, addr)
+	for addr, cl := range p.clients {
+		cl.Close()
+		delete(p.clients, addr)
 	}
 }
 
@@ -164,241 +165,483 @@ func (p *ConnPool) Close() {
 // Proxy Server
 // ──────────────────────────────────────────────────────────────────────────────
 
+// backendTimeout bounds each command forwarded to a cache server.
+const backendTimeout = 2 * time.Second
+
 type Proxy struct {
 	ring *HashRing
 	pool *ConnPool
+	cmds *command.Table
 }
 
 func NewProxy(replicas int) *Proxy {
-	return &Proxy{
+	p := &Proxy{
 		ring: NewHashRing(replicas),
 		pool: NewConnPool(),
 	}
+	p.cmds = p.newTable()
+	return p
 }
 
-func writeLine(w *bufio.Writer, line string) error {
-	_, err := w.WriteString(line + "\n")
-	if err != nil {
-		return err
+// forwardToServer sends one command to addr and returns its reply. Both
+// travel as length-prefixed RESP, so values pass through byte for byte.
+// Error replies come back as values; err is for connection failures.
+func (p *Proxy) forwardToServer(addr string, args ...[]byte) (resp.Value, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), backendTimeout)
+	defer cancel()
+	if !p.retrySafe(args) {
+		ctx = client.NoRetry(ctx)
 	}
-	return w.Flush()
-}
 
-func (p *Proxy) forwardToServer(addr string, cmd string) ([]string, error) {
-	_, r, w, err := p.pool.Get(addr)
+	reply, err := p.pool.Get(addr).DoArgs(ctx, args)
+	var replyErr resp.ReplyError
+	if errors.As(err, &replyErr) {
+		return reply, nil
+	}
 	if err != nil {
-		p.pool.Remove(addr)
-		return nil, fmt.Errorf("connect to %s: %w", addr, err)
+		return resp.Value{}, fmt.Errorf("%s: %w", addr, err)
 	}
+	return reply, nil
+}
 
-	if err := writeLine(w, cmd); err != nil {
-		p.pool.Remove(addr)
-		return nil, fmt.Errorf("write to %s: %w", addr, err)
+// retrySafe reports whether args may be resent after the connection broke,
+// not knowing if the server ran it: reads, and writes that give the same
+// state and reply when run twice. A resent DEL would reply 0, a resent
+// MSETNX 0 for its own write, and a resent SET NX or INCR against a
+// raw-tcp backend would misreport or apply twice.
+func (p *Proxy) retrySafe(args [][]byte) bool {
+	spec, ok := p.cmds.Lookup(args[0])
+	if !ok || !spec.Has(command.Write) {
+		return ok
 	}
+	switch spec.Name {
+	case "SET":
+		return len(args) == 3
+	case "MSET":
+		return true
+	}
+	return false
+}
 
-	line, err := r.ReadString('\n')
-	if err != nil {
-		p.pool.Remove(addr)
-		return nil, fmt.Errorf("read from %s: %w", addr, err)
+// groupByNode maps each server to the indexes of the keys it owns, in the
+// order the keys were given.
+func (p *Proxy) groupByNode(keys [][]byte) (map[string][]int, bool) {
+	groups := make(map[string][]int)
+	for i, key := range keys {
+		nodeAddr, ok := p.ring.Get(string(key))
+		if !ok {
+			return nil, false
+		}
+		groups[nodeAddr] = append(groups[nodeAddr], i)
 	}
-	line = strings.TrimSpace(line)
+	return groups, true
+}
 
-	responses := []string{line}
+// mget fans MGET out to the servers owning keys and puts the values back in
+// the order of keys. Each server's part is atomic; the whole is not.
+func (p *Proxy) mget(keys [][]byte) ([]resp.Value, error) {
+	groups, ok := p.groupByNode(keys)
+	if !ok {
+		return nil, fmt.Errorf("no servers available")
+	}
+	vals := make([]resp.Value, len(keys))
+	for nodeAddr, idx := range groups {
+		sub := [][]byte{[]byte("MGET")}
+		for _, i := range idx {
+			sub = append(sub, keys[i])
+		}
+		log.Printf("[proxy] MGET %q -> routing to %s", sub[1:], nodeAddr)
+		reply, err := p.forwardToServer(nodeAddr, sub...)
+		if err != nil {
+			return nil, err
+		}
+		if err := reply.Err(); err != nil {
+			return nil, fmt.Errorf("%s: %w", nodeAddr, err)
+		}
+		if len(reply.Elems) != len(idx) {
+			return nil, fmt.Errorf("%s: short MGET reply", nodeAddr)
+		}
+		for j, i := range idx {
+			vals[i] = reply.Elems[j]
+		}
+	}
+	return vals, nil
+}
 
-	// Handle bulk strings ($N) and arrays (*N)
-	if len(line) > 0 && line[0] == '$' {
-		var length int
-		fmt.Sscanf(line, "$%d", &length)
-		if length >= 0 {
-			valueLine, _ := r.ReadString('\n')
-			responses = append(responses, strings.TrimSpace(valueLine))
+// mset splits MSET pairs by server. Each server applies its share
+// atomically, but a failure part-way leaves the earlier servers written.
+func (p *Proxy) mset(kv [][]byte) error {
+	keys := make([][]byte, 0, len(kv)/2)
+	for i := 0; i < len(kv); i += 2 {
+		keys = append(keys, kv[i])
+	}
+	groups, ok := p.groupByNode(keys)
+	if !ok {
+		return fmt.Errorf("no servers available")
+	}
+	for nodeAddr, idx := range groups {
+		sub := [][]byte{[]byte("MSET")}
+		for _, i := range idx {
+			sub = append(sub, kv[2*i], kv[2*i+1])
 		}
-	} else if len(line) > 0 && line[0] == '*' {
-		var count int
-		fmt.Sscanf(line, "*%d", &count)
-		for i := 0; i < count; i++ {
-			itemLine, _ := r.ReadString('\n')
-			responses = append(responses, strings.TrimSpace(itemLine))
+		log.Printf("[proxy] MSET %d keys -> routing to %s", len(idx), nodeAddr)
+		reply, err := p.forwardToServer(nodeAddr, sub...)
+		if err != nil {
+			return err
 		}
+		if err := reply.Err(); err != nil {
+			return fmt.Errorf("%s: %w", nodeAddr, err)
+		}
+	}
+	return nil
+}
+
+// A cluster SCAN cursor is the cursor of the server being scanned shifted
+// above that server's index in the sorted server list: servers are scanned
+// one after the other, each to the end. As in Redis Cluster, keys present
+// throughout are returned exactly once only if no server is added or
+// removed during the iteration.
+const (
+	scanNodeBits = 16
+	scanNodeMask = 1<<scanNodeBits - 1
+)
+
+// scan runs one step of a cluster-wide SCAN on a single server and returns
+// the cluster cursor for the next step, 0 after the last server.
+func (p *Proxy) scan(sa command.ScanArgs) (next uint64, keys []resp.Value, err error) {
+	nodes := p.ring.Nodes()
+	if len(nodes) == 0 {
+		return 0, nil, fmt.Errorf("no servers available")
+	}
+	i, cursor := int(sa.Cursor&scanNodeMask), sa.Cursor>>scanNodeBits
+	if i >= len(nodes) {
+		return 0, nil, nil // servers were removed: nothing left to scan
+	}
+
+	sub := [][]byte{[]byte("SCAN"), strconv.AppendUint(nil, cursor, 10)}
+	if sa.Match != "" {
+		sub = append(sub, []byte("MATCH"), []byte(sa.Match))
+	}
+	sub = append(sub, []byte("COUNT"), strconv.AppendInt(nil, int64(sa.Count), 10))
+	log.Printf("[proxy] SCAN %d -> routing to %s", cursor, nodes[i])
+	reply, err := p.forwardToServer(nodes[i], sub...)
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := reply.Err(); err != nil {
+		return 0, nil, fmt.Errorf("%s: %w", nodes[i], err)
+	}
+	if len(reply.Elems) != 2 {
+		return 0, nil, fmt.Errorf("%s: bad SCAN reply", nodes[i])
+	}
+	cursor, err = strconv.ParseUint(string(reply.Elems[0].Str), 10, 64)
+	if err != nil || cursor > math.MaxUint64>>scanNodeBits {
+		return 0, nil, fmt.Errorf("%s: bad SCAN cursor %q", nodes[i], reply.Elems[0].Str)
 	}
 
-	return responses, nil
+	switch {
+	case cursor != 0:
+		next = cursor<<scanNodeBits | uint64(i)
+	case i+1 < len(nodes):
+		next = uint64(i + 1)
+	}
+	return next, reply.Elems[1].Elems, nil
 }
 
 func (p *Proxy) handleConn(conn net.Conn) {
 	defer conn.Close()
 
-	r := bufio.NewReader(conn)
-	w := bufio.NewWriter(conn)
-
-	_ = writeLine(w, "+OK proxy ready (type HELP for commands)")
+	r := resp.NewReader(conn)
+	w := resp.NewWriter(conn)
 
+	c := &command.Conn{W: w}
+	// pending holds pipelined single-key commands not yet forwarded.
+	var pending [][][]byte
 	for {
-		line, err := r.ReadString('\n')
+		args, err := r.ReadCommand()
 		if err != nil {
+			if errors.Is(err, resp.ErrProtocol) {
+				p.forwardBatch(c, pending)
+				w.WriteError(err.Error())
+				_ = w.Flush()
+			}
 			return
 		}
-		line = strings.TrimSpace(line)
-		if line == "" {
+		if len(args) == 0 {
 			continue
 		}
+		if p.forwarded(args) {
+			pending = append(pending, args)
+		} else {
+			// Commands run in the client's order: forward what is pending
+			// before e.g. an ADD_SERVER changes the routing.
+			p.forwardBatch(c, pending)
+			pending = pending[:0]
+			p.cmds.Dispatch(c, args)
+			if c.Quit {
+				_ = w.Flush()
+				return
+			}
+		}
 
-		parts := strings.Split(line, " ")
-		cmd := strings.ToUpper(parts[0])
+		// More pipelined commands are waiting: collect them, then forward
+		// and reply to the whole batch at once.
+		if r.Buffered() > 0 && len(pending) < maxBatch {
+			continue
+		}
+		p.forwardBatch(c, pending)
+		pending = pending[:0]
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
 
-		switch cmd {
-		case "HELP":
-			_ = writeLine(w, "+Commands: SET/GET/DEL/KEYS, ADD_SERVER/REMOVE_SERVER/SERVERS, ROUTE, PING, QUIT")
+// maxBatch bounds how many pipelined commands the proxy holds per client.
+const maxBatch = 1000
 
-		case "PING":
-			_ = writeLine(w, "+PONG")
+// forwarded reports whether args is a single-key command with valid arity,
+// which the proxy passes to the key's server as is.
+func (p *Proxy) forwarded(args [][]byte) bool {
+	spec, ok := p.cmds.Lookup(args[0])
+	return ok && spec.SingleKey() && spec.ArityOK(len(args))
+}
 
-		case "QUIT":
-			_ = writeLine(w, "+BYE")
-			return
+// forwardBatch sends single-key commands to their servers as one pipeline
+// per server, all servers at once, and writes the replies in the client's
+// order. Commands on the same key go to the same server in order, so they
+// still apply in order.
+func (p *Proxy) forwardBatch(c *command.Conn, cmds [][][]byte) {
+	if len(cmds) <= 1 {
+		for _, args := range cmds {
+			p.cmds.Dispatch(c, args)
+		}
+		return
+	}
 
-		// ─────────────────────────────────────────────────────────────────────
-		// Server management commands
-		// ─────────────────────────────────────────────────────────────────────
+	replies := make([]resp.Value, len(cmds))
+	groups, ok := p.groupByNode(cmdKeys(cmds))
+	if !ok {
+		for range cmds {
+			c.W.WriteError("ERR no servers available")
+		}
+		return
+	}
 
-		case "ADD_SERVER":
-			if len(parts) != 2 {
-				_ = writeLine(w, "-ERR usage: ADD_SERVER host:port")
-				continue
+	var wg sync.WaitGroup
+	for nodeAddr, idx := range groups {
+		wg.Add(1)
+		go func(nodeAddr string, idx []int) {
+			defer wg.Done()
+			log.Printf("[proxy] pipeline of %d commands -> routing to %s", len(idx), nodeAddr)
+
+			ctx, cancel := context.WithTimeout(context.Background(), backendTimeout)
+			defer cancel()
+			pl := p.pool.Get(nodeAddr).Pipeline()
+			retry := true
+			for _, i := range idx {
+				pl.DoArgs(cmds[i])
+				retry = retry && p.retrySafe(cmds[i])
 			}
-			addr := parts[1]
-			p.ring.Add(addr)
-			log.Printf("[proxy] Added server: %s", addr)
-			_ = writeLine(w, fmt.Sprintf("+OK added %s", addr))
-
-		case "REMOVE_SERVER":
-			if len(parts) != 2 {
-				_ = writeLine(w, "-ERR usage: REMOVE_SERVER host:port")
-				continue
+			if !retry {
+				ctx = client.NoRetry(ctx)
 			}
-			addr := parts[1]
-			p.ring.Remove(addr)
-			p.pool.Remove(addr)
-			log.Printf("[proxy] Removed server: %s", addr)
-			_ = writeLine(w, fmt.Sprintf("+OK removed %s", addr))
-
-		case "SERVERS":
-			nodes := p.ring.Nodes()
-			_ = writeLine(w, fmt.Sprintf("*%d", len(nodes)))
-			for _, n := range nodes {
-				_ = writeLine(w, "+"+n)
+			vals, err := pl.Exec(ctx)
+			for j, i := range idx {
+				if err != nil {
+					replies[i] = resp.Value{Type: resp.Error, Str: []byte("ERR " + nodeAddr + ": " + err.Error())}
+					continue
+				}
+				replies[i] = vals[j]
 			}
+		}(nodeAddr, idx)
+	}
+	wg.Wait()
 
-		case "ROUTE":
-			// Show which server a key would route to
-			if len(parts) != 2 {
-				_ = writeLine(w, "-ERR usage: ROUTE key")
-				continue
-			}
-			key := parts[1]
-			if nodeAddr, ok := p.ring.Get(key); ok {
-				_ = writeLine(w, "+"+nodeAddr)
-			} else {
-				_ = writeLine(w, "-ERR no servers available")
-			}
+	for _, v := range replies {
+		c.W.WriteValue(v)
+	}
+}
 
-		// ─────────────────────────────────────────────────────────────────────
-		// Data commands (forwarded via consistent hashing)
-		// ─────────────────────────────────────────────────────────────────────
+// cmdKeys returns the key of each single-key command.
+func cmdKeys(cmds [][][]byte) [][]byte {
+	keys := make([][]byte, len(cmds))
+	for i, args := range cmds {
+		keys[i] = args[1]
+	}
+	return keys
+}
 
-		case "SET":
-			if len(parts) < 3 {
-				_ = writeLine(w, "-ERR usage: SET key value")
-				continue
-			}
-			key := parts[1]
-			nodeAddr, ok := p.ring.Get(key)
-			if !ok {
-				_ = writeLine(w, "-ERR no servers available")
-				continue
-			}
-			log.Printf("[proxy] SET %s -> routing to %s", key, nodeAddr)
-			responses, err := p.forwardToServer(nodeAddr, line)
-			if err != nil {
-				_ = writeLine(w, "-ERR "+err.Error())
-				continue
-			}
-			for _, resp := range responses {
-				_ = writeLine(w, resp)
-			}
+// newTable builds the proxy's commands: cluster management, every
+// single-key command forwarded to the key's server, and the multi-key ones
+// split or aggregated across servers.
+func (p *Proxy) newTable() *command.Table {
+	t := command.NewTable()
+	t.Handle("HELP", command.Help(t))
+	t.Handle("PING", command.Ping)
+	t.Handle("ECHO", command.Echo)
+	t.Handle("QUIT", command.Quit)
+	t.Handle("HELLO", command.Hello("server", "proxy", "mode", "cluster"))
+	t.Handle("SELECT", command.OK)
+	t.Handle("CLIENT", command.OK)
+
+	// ─────────────────────────────────────────────────────────────────────
+	// Server management commands
+	// ─────────────────────────────────────────────────────────────────────
+
+	t.Handle("ADD_SERVER", func(c *command.Conn, args [][]byte) {
+		addr := string(args[1])
+		p.ring.Add(addr)
+		log.Printf("[proxy] Added server: %s", addr)
+		c.W.WriteSimple("OK added " + addr)
+	})
+
+	t.Handle("REMOVE_SERVER", func(c *command.Conn, args [][]byte) {
+		addr := string(args[1])
+		p.ring.Remove(addr)
+		p.pool.Remove(addr)
+		log.Printf("[proxy] Removed server: %s", addr)
+		c.W.WriteSimple("OK removed " + addr)
+	})
+
+	t.Handle("SERVERS", func(c *command.Conn, args [][]byte) {
+		nodes := p.ring.Nodes()
+		c.W.WriteArray(len(nodes))
+		for _, n := range nodes {
+			c.W.WriteBulkString(n)
+		}
+	})
+
+	// Show which server a key would route to
+	t.Handle("ROUTE", func(c *command.Conn, args [][]byte) {
+		if nodeAddr, ok := p.ring.Get(string(args[1])); ok {
+			c.W.WriteSimple(nodeAddr)
+		} else {
+			c.W.WriteError("ERR no servers available")
+		}
+	})
 
-		case "GET":
-			if len(parts) != 2 {
-				_ = writeLine(w, "-ERR usage: GET key")
-				continue
-			}
-			key := parts[1]
-			nodeAddr, ok := p.ring.Get(key)
-			if !ok {
-				_ = writeLine(w, "-ERR no servers available")
-				continue
-			}
-			log.Printf("[proxy] GET %s -> routing to %s", key, nodeAddr)
-			responses, err := p.forwardToServer(nodeAddr, line)
-			if err != nil {
-				_ = writeLine(w, "-ERR "+err.Error())
-				continue
-			}
-			for _, resp := range responses {
-				_ = writeLine(w, resp)
-			}
+	// ─────────────────────────────────────────────────────────────────────
+	// Data commands (forwarded via consistent hashing)
+	// ─────────────────────────────────────────────────────────────────────
 
-		case "DEL":
-			if len(parts) != 2 {
-				_ = writeLine(w, "-ERR usage: DEL key")
-				continue
-			}
-			key := parts[1]
-			nodeAddr, ok := p.ring.Get(key)
-			if !ok {
-				_ = writeLine(w, "-ERR no servers available")
-				continue
-			}
-			log.Printf("[proxy] DEL %s -> routing to %s", key, nodeAddr)
-			responses, err := p.forwardToServer(nodeAddr, line)
-			if err != nil {
-				_ = writeLine(w, "-ERR "+err.Error())
-				continue
-			}
-			for _, resp := range responses {
-				_ = writeLine(w, resp)
-			}
+	// Only what the cache servers serve: forwarding e.g. INCR would list
+	// it in COMMAND and then fail with the backend's "unknown command".
+	for _, name := range command.StoreCommands {
+		if spec, _ := command.Lookup(name); spec.SingleKey() {
+			t.Handle(name, p.forward)
+		}
+	}
 
-		case "KEYS":
-			// Query all servers and aggregate keys
-			nodes := p.ring.Nodes()
-			if len(nodes) == 0 {
-				_ = writeLine(w, "*0")
+	t.Handle("MGET", func(c *command.Conn, args [][]byte) {
+		vals, err := p.mget(args[1:])
+		if err != nil {
+			c.W.WriteError("ERR " + err.Error())
+			return
+		}
+		c.W.WriteArray(len(vals))
+		for _, v := range vals {
+			c.W.WriteValue(v)
+		}
+	})
+
+	t.Handle("MSET", func(c *command.Conn, args [][]byte) {
+		if len(args)%2 == 0 {
+			c.UsageError()
+			return
+		}
+		if err := p.mset(args[1:]); err != nil {
+			c.W.WriteError("ERR " + err.Error())
+			return
+		}
+		c.W.WriteSimple("OK")
+	})
+
+	// All-or-nothing needs a single server: like Redis Cluster, refuse keys
+	// that hash to different servers instead of faking atomicity.
+	t.Handle("MSETNX", func(c *command.Conn, args [][]byte) {
+		if len(args)%2 == 0 {
+			c.UsageError()
+			return
+		}
+		var keys [][]byte
+		for i := 1; i < len(args); i += 2 {
+			keys = append(keys, args[i])
+		}
+		groups, ok := p.groupByNode(keys)
+		if !ok {
+			c.W.WriteError("ERR no servers available")
+			return
+		}
+		if len(groups) > 1 {
+			c.W.WriteError("CROSSSLOT Keys in request don't hash to the same server")
+			return
+		}
+		for nodeAddr := range groups {
+			log.Printf("[proxy] MSETNX %q -> routing to %s", keys, nodeAddr)
+			p.forwardTo(c, nodeAddr, args)
+		}
+	})
+
+	// Query all servers and aggregate keys
+	t.Handle("KEYS", func(c *command.Conn, args [][]byte) {
+		var allKeys []resp.Value
+		for _, nodeAddr := range p.ring.Nodes() {
+			reply, err := p.forwardToServer(nodeAddr, []byte("KEYS"))
+			if err != nil {
+				log.Printf("[proxy] KEYS from %s: %v", nodeAddr, err)
 				continue
 			}
-			var allKeys []string
-			for _, nodeAddr := range nodes {
-				responses, err := p.forwardToServer(nodeAddr, "KEYS")
-				if err != nil {
-					log.Printf("[proxy] KEYS from %s: %v", nodeAddr, err)
-					continue
-				}
-				// Skip the *N header and collect key names
-				for i := 1; i < len(responses); i++ {
-					if strings.HasPrefix(responses[i], "+") {
-						allKeys = append(allKeys, responses[i][1:])
-					}
-				}
-			}
-			_ = writeLine(w, fmt.Sprintf("*%d", len(allKeys)))
-			for _, k := range allKeys {
-				_ = writeLine(w, "+"+k)
-			}
+			allKeys = append(allKeys, reply.Elems...)
+		}
+		c.W.WriteArray(len(allKeys))
+		for _, k := range allKeys {
+			c.W.WriteValue(k)
+		}
+	})
 
-		default:
-			_ = writeLine(w, "-ERR unknown command (type HELP)")
+	// Iterate the whole cluster, server by server, without KEYS' buffering
+	t.Handle("SCAN", func(c *command.Conn, args [][]byte) {
+		sa, msg := command.ParseScan(args)
+		if msg != "" {
+			c.W.WriteError(msg)
+			return
 		}
+		next, keys, err := p.scan(sa)
+		if err != nil {
+			c.W.WriteError("ERR " + err.Error())
+			return
+		}
+		c.W.WriteArray(2)
+		c.W.WriteBulkString(strconv.FormatUint(next, 10))
+		c.W.WriteArray(len(keys))
+		for _, k := range keys {
+			c.W.WriteValue(k)
+		}
+	})
+	return t
+}
+
+// forward sends a single-key command to the key's server.
+func (p *Proxy) forward(c *command.Conn, args [][]byte) {
+	key := string(args[1])
+	nodeAddr, ok := p.ring.Get(key)
+	if !ok {
+		c.W.WriteError("ERR no servers available")
+		return
+	}
+	log.Printf("[proxy] %s %s -> routing to %s", strings.ToUpper(string(args[0])), key, nodeAddr)
+	p.forwardTo(c, nodeAddr, args)
+}
+
+// forwardTo relays args to nodeAddr and its reply back to the client.
+func (p *Proxy) forwardTo(c *command.Conn, nodeAddr string, args [][]byte) {
+	reply, err := p.forwardToServer(nodeAddr, args...)
+	if err != nil {
+		c.W.WriteError("ERR " + err.Error())
+		return
 	}
+	c.W.WriteValue(reply)
 }
 
 func main() {
@@ -414,7 +657,7 @@ func main() {
 		log.Fatalf("[proxy] listen: %v", err)
 	}
 	log.Printf("[proxy] listening on %s (replicas=%d)", addr, *replicas)
-	log.Printf("[proxy] Use 'nc %s' to connect", addr)
+	log.Printf("[proxy] Use 'redis-cli -p %d' or 'nc 127.0.0.1 %d' to connect", *port, *port)
 
 	for {
 		conn, err := ln.Accept()
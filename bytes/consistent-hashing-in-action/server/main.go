@@ -1,150 +1,170 @@
 package main
 
 import (
-	"bufio"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"net"
-	"strings"
 	"sync"
+
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/command"
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/keyspace"
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/resp"
 )
 
+// Store keeps binary-safe values. They are replaced, never modified in
+// place, so readers may share them.
 type Store struct {
 	mu sync.RWMutex
-	m  map[string]string
+	m  *keyspace.Map[[]byte]
 }
 
 func NewStore() *Store {
-	return &Store{m: make(map[string]string)}
+	return &Store{m: keyspace.New[[]byte]()}
 }
 
-func (s *Store) Set(k, v string) {
+// Set stores a copy of v.
+func (s *Store) Set(k string, v []byte) {
 	s.mu.Lock()
-	s.m[k] = v
+	s.m.Set(k, append([]byte{}, v...))
 	s.mu.Unlock()
 }
 
-func (s *Store) Get(k string) (string, bool) {
+// Get returns the value at k; callers must not modify it.
+func (s *Store) Get(k string) ([]byte, bool) {
 	s.mu.RLock()
-	v, ok := s.m[k]
+	v, ok := s.m.Get(k)
 	s.mu.RUnlock()
 	return v, ok
 }
 
-func (s *Store) Del(k string) bool {
+// MGet reads every key under one lock, so the values are a snapshot no
+// concurrent MSET can tear.
+func (s *Store) MGet(keys []string) (vals [][]byte, found []bool) {
+	vals, found = make([][]byte, len(keys)), make([]bool, len(keys))
+	s.mu.RLock()
+	for i, k := range keys {
+		vals[i], found[i] = s.m.Get(k)
+	}
+	s.mu.RUnlock()
+	return vals, found
+}
+
+// MSet writes key/value pairs (kv = k1, v1, k2, v2, ...) under one lock.
+func (s *Store) MSet(kv [][]byte) {
 	s.mu.Lock()
-	_, ok := s.m[k]
-	if ok {
-		delete(s.m, k)
+	for i := 0; i+1 < len(kv); i += 2 {
+		s.m.Set(string(kv[i]), append([]byte{}, kv[i+1]...))
 	}
 	s.mu.Unlock()
+}
+
+// MSetNX is MSet that writes nothing if any of the keys exists.
+func (s *Store) MSetNX(kv [][]byte) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := 0; i < len(kv); i += 2 {
+		if _, ok := s.m.Get(string(kv[i])); ok {
+			return false
+		}
+	}
+	for i := 0; i+1 < len(kv); i += 2 {
+		s.m.Set(string(kv[i]), append([]byte{}, kv[i+1]...))
+	}
+	return true
+}
+
+func (s *Store) Del(k string) bool {
+	s.mu.Lock()
+	_, ok := s.m.Get(k)
+	s.m.Delete(k)
+	s.mu.Unlock()
 	return ok
 }
 
 func (s *Store) Keys() []string {
 	s.mu.RLock()
-	keys := make([]string, 0, len(s.m))
-	for k := range s.m {
+	keys := make([]string, 0, s.m.Len())
+	s.m.Range(func(k string, _ []byte) bool {
 		keys = append(keys, k)
-	}
+		return true
+	})
 	s.mu.RUnlock()
 	return keys
 }
 
-func writeLine(w *bufio.Writer, line string) error {
-	_, err := w.WriteString(line + "\n")
-	if err != nil {
-		return err
-	}
-	return w.Flush()
+// Scan is one step of a SCAN iteration: the keys matching the glob match
+// ("" = all) among at least count keys from the cursor on, and the cursor
+// for the next step, 0 when done.
+func (s *Store) Scan(cursor uint64, match string, count int) (next uint64, keys []string) {
+	s.mu.RLock()
+	next = s.m.Scan(cursor, count, func(k string, _ []byte) {
+		if match == "" || keyspace.Match(match, k) {
+			keys = append(keys, k)
+		}
+	})
+	s.mu.RUnlock()
+	return next, keys
 }
 
-func handleConn(conn net.Conn, st *Store, serverName string) {
+func handleConn(conn net.Conn, cmds *command.Table, serverName string) {
 	defer conn.Close()
 
-	r := bufio.NewReader(conn)
-	w := bufio.NewWriter(conn)
-
-	_ = writeLine(w, fmt.Sprintf("+OK %s ready", serverName))
+	r := resp.NewReader(conn)
+	w := resp.NewWriter(conn)
 
+	c := &command.Conn{W: w}
 	for {
-		line, err := r.ReadString('\n')
+		args, err := r.ReadCommand()
 		if err != nil {
+			if errors.Is(err, resp.ErrProtocol) {
+				w.WriteError(err.Error())
+				_ = w.Flush()
+			}
 			return
 		}
-		line = strings.TrimSpace(line)
-		if line == "" {
+		if len(args) == 0 {
 			continue
 		}
+		if spec, ok := cmds.Lookup(args[0]); ok && spec.Has(command.Write|command.ReadOnly) {
+			log.Printf("[%s] %s %q", serverName, spec.Name, args[1:])
+		}
 
-		parts := strings.Split(line, " ")
-		cmd := strings.ToUpper(parts[0])
-
-		switch cmd {
-		case "PING":
-			_ = writeLine(w, "+PONG")
-
-		case "WHOAMI":
-			_ = writeLine(w, "+"+serverName)
-
-		case "QUIT":
-			_ = writeLine(w, "+BYE")
+		cmds.Dispatch(c, args)
+		if c.Quit {
+			_ = w.Flush()
 			return
+		}
 
-		case "SET":
-			if len(parts) < 3 {
-				_ = writeLine(w, "-ERR usage: SET key value")
-				continue
-			}
-			key := parts[1]
-			value := strings.TrimSpace(strings.TrimPrefix(line, parts[0]+" "+key))
-			st.Set(key, value)
-			log.Printf("[%s] SET %s = %s", serverName, key, value)
-			_ = writeLine(w, "+OK")
-
-		case "GET":
-			if len(parts) != 2 {
-				_ = writeLine(w, "-ERR usage: GET key")
-				continue
-			}
-			key := parts[1]
-			if v, ok := st.Get(key); ok {
-				log.Printf("[%s] GET %s -> %s", serverName, key, v)
-				_ = writeLine(w, fmt.Sprintf("$%d", len(v)))
-				_ = writeLine(w, v)
-			} else {
-				log.Printf("[%s] GET %s -> (nil)", serverName, key)
-				_ = writeLine(w, "$-1")
-			}
-
-		case "DEL":
-			if len(parts) != 2 {
-				_ = writeLine(w, "-ERR usage: DEL key")
-				continue
-			}
-			key := parts[1]
-			if st.Del(key) {
-				log.Printf("[%s] DEL %s -> 1", serverName, key)
-				_ = writeLine(w, ":1")
-			} else {
-				log.Printf("[%s] DEL %s -> 0", serverName, key)
-				_ = writeLine(w, ":0")
-			}
-
-		case "KEYS":
-			keys := st.Keys()
-			_ = writeLine(w, fmt.Sprintf("*%d", len(keys)))
-			for _, k := range keys {
-				_ = writeLine(w, "+"+k)
-			}
-
-		default:
-			_ = writeLine(w, "-ERR unknown command")
+		// Replies to pipelined commands go out together once the read
+		// buffer is drained.
+		if r.Buffered() > 0 {
+			continue
+		}
+		if err := w.Flush(); err != nil {
+			return
 		}
 	}
 }
 
+// newTable builds the commands a cache server serves from st.
+func newTable(st *Store, serverName string) *command.Table {
+	t := command.NewTable()
+	t.Handle("PING", command.Ping)
+	t.Handle("ECHO", command.Echo)
+	t.Handle("QUIT", command.Quit)
+	t.Handle("HELLO", command.Hello("server", serverName, "mode", "standalone"))
+	t.Handle("SELECT", command.OK)
+	t.Handle("CLIENT", command.OK)
+	t.Handle("WHOAMI", func(c *command.Conn, args [][]byte) {
+		c.W.WriteSimple(serverName)
+	})
+	command.HandleStore(t, st)
+	return t
+}
+
 func main() {
 	port := flag.Int("port", 6381, "port to listen on")
 	name := flag.String("name", "", "server name (defaults to cache-<port>)")
@@ -156,7 +176,7 @@ func main() {
 	}
 
 	addr := fmt.Sprintf("127.0.0.1:%d", *port)
-	st := NewStore()
+	cmds := newTable(NewStore(), serverName)
 
 	ln, err := net.Listen("tcp", addr)
 	if err != nil {
@@ -170,6 +190,6 @@ func main() {
 			log.Printf("[%s] accept: %v", serverName, err)
 			continue
 		}
-		go handleConn(conn, st, serverName)
+		go handleConn(conn, cmds, serverName)
 	}
 }
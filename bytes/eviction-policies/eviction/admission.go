@@ -0,0 +1,64 @@
+package eviction
+
+import "github.com/vnscriptkid/sd-keyvalue-store/bytes/eviction-policies/lib"
+
+// TinyLFUAdmission adds a TinyLFU admission filter to any evictor: a new key
+// only replaces the inner policy's victim if it was requested more often,
+// according to a count-min sketch that also counts rejected attempts. One-hit
+// wonders never displace the hot set; keys that keep coming back get in.
+type TinyLFUAdmission struct {
+	Evictor
+	sketch *countMinSketch
+	// admitted is the key whose attempt Admit already counted. It only
+	// stands for the OnAdd of that same write, which follows with no other
+	// OnAdd, OnGet or OnUpdate in between, so each of those clears it: a
+	// write may still fail after Admit, and then its key must count again.
+	admitted string
+}
+
+// NewTinyLFUAdmission sizes the sketch for capacity keys, typically the
+// maxKeys given to the store.
+func NewTinyLFUAdmission(inner Evictor, capacity int) *TinyLFUAdmission {
+	return &TinyLFUAdmission{
+		Evictor: inner,
+		sketch:  newCountMinSketch(capacity),
+	}
+}
+
+func (e *TinyLFUAdmission) Name() string { return e.Evictor.Name() + "+TINYLFU" }
+
+func (e *TinyLFUAdmission) Admit(candidate, victim *lib.Entry) bool {
+	e.admitted = ""
+	e.sketch.increment(candidate.Key)
+	if e.sketch.estimate(candidate.Key) <= e.sketch.estimate(victim.Key) {
+		return false
+	}
+	e.admitted = candidate.Key
+	return true
+}
+
+func (e *TinyLFUAdmission) OnAdd(en *lib.Entry) {
+	counted := e.admitted == en.Key
+	e.admitted = ""
+	if !counted {
+		e.sketch.increment(en.Key)
+	}
+	e.Evictor.OnAdd(en)
+}
+
+func (e *TinyLFUAdmission) OnGet(en *lib.Entry) {
+	e.admitted = ""
+	e.sketch.increment(en.Key)
+	e.Evictor.OnGet(en)
+}
+
+func (e *TinyLFUAdmission) OnUpdate(en *lib.Entry) {
+	e.admitted = ""
+	e.sketch.increment(en.Key)
+	e.Evictor.OnUpdate(en)
+}
+
+// Frequency is the inner policy's count, not the admission sketch's.
+func (e *TinyLFUAdmission) Frequency(en *lib.Entry) (int, bool) { return frequency(e.Evictor, en) }
+
+func (e *TinyLFUAdmission) NodeBytes() int64 { return nodeBytes(e.Evictor) }
@@ -0,0 +1,102 @@
+package eviction
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/eviction-policies/lib"
+)
+
+const (
+	lfuInitVal = 5   // new keys start here so they aren't evicted right away
+	lfuMaxVal  = 255 // 8-bit counter saturates
+)
+
+// ApproxLFUEvictor is the Redis LFU policy: each entry keeps an 8-bit
+// Morris-style counter that grows logarithmically with accesses and decays
+// over time, and victims are picked by sampling like ApproxLRUEvictor.
+// Unlike LFUEvictor, keys that were hot long ago eventually cool down.
+type ApproxLFUEvictor struct {
+	logFactor    int    // lfu-log-factor: higher = slower counter growth
+	decayMinutes uint16 // lfu-decay-time: minutes per counter decrement, 0 = never
+	minutes      Clock
+	now          uint16 // minute clock reading taken once per Victim call
+	rnd          *rand.Rand
+	sampledPolicy
+}
+
+// NewApproxLFUEvictor samples `samples` keys per Victim call (5 if <= 0).
+// Redis defaults are logFactor=10 and decayTime=1m; decayTime is rounded
+// down to whole minutes.
+func NewApproxLFUEvictor(samples, logFactor int, decayTime time.Duration) *ApproxLFUEvictor {
+	e := &ApproxLFUEvictor{
+		logFactor:    logFactor,
+		decayMinutes: uint16(decayTime / time.Minute),
+		minutes:      WallClock(time.Minute),
+		rnd:          rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	e.sampledPolicy = newSampledPolicy(samples, e.coldness)
+	return e
+}
+
+func (e *ApproxLFUEvictor) Name() string { return "APPROX-LFU" }
+
+// decayed returns the counter after applying the decay owed since LFUDecrTime.
+func (e *ApproxLFUEvictor) decayed(en *lib.Entry, now uint16) uint8 {
+	if e.decayMinutes == 0 {
+		return en.LFUCounter
+	}
+	periods := (now - en.LFUDecrTime) / e.decayMinutes
+	if int(periods) >= int(en.LFUCounter) {
+		return 0
+	}
+	return en.LFUCounter - uint8(periods)
+}
+
+// logIncr bumps the counter with probability 1/((counter-init)*logFactor+1).
+func (e *ApproxLFUEvictor) logIncr(counter uint8) uint8 {
+	if counter == lfuMaxVal {
+		return counter
+	}
+	base := float64(counter) - lfuInitVal
+	if base < 0 {
+		base = 0
+	}
+	if e.rnd.Float64() < 1.0/(base*float64(e.logFactor)+1) {
+		counter++
+	}
+	return counter
+}
+
+func (e *ApproxLFUEvictor) touch(en *lib.Entry) {
+	now := uint16(e.minutes())
+	en.LFUCounter = e.logIncr(e.decayed(en, now))
+	en.LFUDecrTime = now
+}
+
+// coldness ranks victims: the lower the decayed counter, the higher the score.
+func (e *ApproxLFUEvictor) coldness(en *lib.Entry) uint64 {
+	return uint64(lfuMaxVal - e.decayed(en, e.now))
+}
+
+func (e *ApproxLFUEvictor) Frequency(en *lib.Entry) (int, bool) {
+	return int(e.decayed(en, uint16(e.minutes()))), true
+}
+
+func (e *ApproxLFUEvictor) OnAdd(en *lib.Entry) {
+	en.LFUCounter = lfuInitVal
+	en.LFUDecrTime = uint16(e.minutes())
+	e.keys.Add(en)
+}
+
+func (e *ApproxLFUEvictor) OnGet(en *lib.Entry)    { e.touch(en) }
+func (e *ApproxLFUEvictor) OnUpdate(en *lib.Entry) { e.touch(en) }
+
+func (e *ApproxLFUEvictor) OnRemove(en *lib.Entry) { e.remove(en.Key) }
+
+func (e *ApproxLFUEvictor) Victim() *lib.Entry {
+	e.now = uint16(e.minutes())
+	return e.victim()
+}
+
+func (e *ApproxLFUEvictor) NodeBytes() int64 { return lib.KeySamplerNodeBytes }
@@ -0,0 +1,52 @@
+package eviction
+
+import (
+	"time"
+
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/eviction-policies/lib"
+)
+
+// ApproxLRUEvictor approximates LRU the way Redis does: reads only stamp
+// lib.Entry.AccessClock, and Victim samples a few keys into an eviction
+// pool and returns the one idle the longest. No list is kept, so OnGet
+// does no pointer surgery.
+type ApproxLRUEvictor struct {
+	clock Clock
+	now   uint32 // clock reading taken once per Victim call
+	sampledPolicy
+}
+
+// NewApproxLRUEvictor samples `samples` keys per Victim call (5 if <= 0).
+// A nil clock means WallClock(time.Millisecond).
+func NewApproxLRUEvictor(samples int, clock Clock) *ApproxLRUEvictor {
+	if clock == nil {
+		clock = WallClock(time.Millisecond)
+	}
+	e := &ApproxLRUEvictor{clock: clock}
+	e.sampledPolicy = newSampledPolicy(samples, e.idle)
+	return e
+}
+
+func (e *ApproxLRUEvictor) Name() string { return "APPROX-LRU" }
+
+// idle is the time since last access in clock ticks.
+func (e *ApproxLRUEvictor) idle(en *lib.Entry) uint64 {
+	return uint64(e.now - en.AccessClock)
+}
+
+func (e *ApproxLRUEvictor) OnAdd(en *lib.Entry) {
+	en.AccessClock = e.clock()
+	e.keys.Add(en)
+}
+
+func (e *ApproxLRUEvictor) OnGet(en *lib.Entry)    { en.AccessClock = e.clock() }
+func (e *ApproxLRUEvictor) OnUpdate(en *lib.Entry) { en.AccessClock = e.clock() }
+
+func (e *ApproxLRUEvictor) OnRemove(en *lib.Entry) { e.remove(en.Key) }
+
+func (e *ApproxLRUEvictor) Victim() *lib.Entry {
+	e.now = e.clock()
+	return e.victim()
+}
+
+func (e *ApproxLRUEvictor) NodeBytes() int64 { return lib.KeySamplerNodeBytes }
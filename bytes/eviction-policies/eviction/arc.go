@@ -0,0 +1,141 @@
+package eviction
+
+import (
+	"container/list"
+	"unsafe"
+
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/eviction-policies/lib"
+)
+
+type arcNode struct {
+	key  string
+	en   *lib.Entry // nil while the key is a ghost
+	in   *list.List // t1, t2, b1 or b2
+	node *list.Element
+}
+
+// ARCEvictor is the Adaptive Replacement Cache: t1 holds keys seen once,
+// t2 keys seen at least twice, and the ghost lists b1/b2 remember recently
+// evicted keys. A hit on a ghost shifts the target size p of t1, so the
+// policy adapts between recency and frequency on its own.
+type ARCEvictor struct {
+	c int // capacity the ghost lists are sized for
+	p int // target size of t1
+
+	t1, t2 *list.List // resident, front=MRU, back=LRU (Value=*arcNode)
+	b1, b2 *list.List // ghosts, front=MRU, back=LRU (Value=*arcNode)
+	nodes  map[string]*arcNode
+}
+
+// NewARCEvictor bounds the history to capacity keys, typically the maxKeys
+// given to the store.
+func NewARCEvictor(capacity int) *ARCEvictor {
+	return &ARCEvictor{
+		c:     capacity,
+		t1:    list.New(),
+		t2:    list.New(),
+		b1:    list.New(),
+		b2:    list.New(),
+		nodes: make(map[string]*arcNode),
+	}
+}
+
+func (e *ARCEvictor) Name() string { return "ARC" }
+
+func (e *ARCEvictor) move(n *arcNode, to *list.List) {
+	if n.in != nil {
+		n.in.Remove(n.node)
+	}
+	n.in = to
+	n.node = to.PushFront(n)
+}
+
+func (e *ARCEvictor) OnAdd(en *lib.Entry) {
+	n, ok := e.nodes[en.Key]
+	switch {
+	case !ok:
+		n = &arcNode{key: en.Key}
+		e.nodes[en.Key] = n
+		n.en = en
+		e.move(n, e.t1)
+	case n.in == e.b1:
+		// recently evicted from t1: t1 was too small
+		e.p = min(e.c, e.p+max(1, e.b2.Len()/e.b1.Len()))
+		n.en = en
+		e.move(n, e.t2)
+	case n.in == e.b2:
+		// recently evicted from t2: t2 was too small
+		e.p = max(0, e.p-max(1, e.b1.Len()/e.b2.Len()))
+		n.en = en
+		e.move(n, e.t2)
+	default:
+		// already resident (defensive): treat as update
+		e.OnUpdate(en)
+		return
+	}
+	e.trimGhosts()
+}
+
+func (e *ARCEvictor) OnGet(en *lib.Entry)    { e.touch(en) }
+func (e *ARCEvictor) OnUpdate(en *lib.Entry) { e.touch(en) }
+
+func (e *ARCEvictor) touch(en *lib.Entry) {
+	n, ok := e.nodes[en.Key]
+	if !ok || n.en == nil {
+		// missing or ghost (desync): re-add
+		e.OnAdd(en)
+		return
+	}
+	n.en = en // keep pointer fresh
+	e.move(n, e.t2)
+}
+
+// OnRemove turns the key into a ghost, so a quick comeback adapts p.
+// Explicit deletes are remembered as well; that only skews p slightly.
+func (e *ARCEvictor) OnRemove(en *lib.Entry) {
+	n, ok := e.nodes[en.Key]
+	if !ok || n.en == nil {
+		return
+	}
+	n.en = nil
+	if n.in == e.t1 {
+		e.move(n, e.b1)
+	} else {
+		e.move(n, e.b2)
+	}
+	e.trimGhosts()
+}
+
+func (e *ARCEvictor) trimGhosts() {
+	for e.b1.Len() > 0 && e.t1.Len()+e.b1.Len() > e.c {
+		e.dropGhost(e.b1)
+	}
+	for e.b2.Len() > 0 && e.t1.Len()+e.t2.Len()+e.b1.Len()+e.b2.Len() > 2*e.c {
+		e.dropGhost(e.b2)
+	}
+}
+
+func (e *ARCEvictor) dropGhost(l *list.List) {
+	n := l.Back().Value.(*arcNode)
+	l.Remove(n.node)
+	delete(e.nodes, n.key)
+}
+
+func (e *ARCEvictor) Victim() *lib.Entry {
+	from := e.t2
+	if e.t1.Len() > 0 && (e.t1.Len() > e.p || e.t2.Len() == 0) {
+		from = e.t1
+	}
+	back := from.Back()
+	if back == nil {
+		return nil
+	}
+	return back.Value.(*arcNode).en
+}
+
+// NodeBytes: the arcNode, its list element and the map slot. Ghosts are
+// bounded by capacity and not charged to resident entries.
+func (e *ARCEvictor) NodeBytes() int64 {
+	return lib.AllocBytes(int64(unsafe.Sizeof(arcNode{}))) + listElementBytes +
+		lib.MapSlotBytes(lib.StringHeaderBytes, lib.PointerBytes)
+}
@@ -0,0 +1,24 @@
+package eviction
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Clock returns a coarse timestamp used by the approximated policies.
+// Differences are taken with uint32 arithmetic, so wrap-around is fine.
+type Clock func() uint32
+
+// WallClock ticks once per resolution of real time (Redis uses 1s for its LRU clock).
+func WallClock(resolution time.Duration) Clock {
+	return func() uint32 {
+		return uint32(time.Now().UnixNano() / int64(resolution))
+	}
+}
+
+// NewCounterClock ticks once per call. Handy for simulations where all
+// accesses happen within the same wall clock tick.
+func NewCounterClock() Clock {
+	var n atomic.Uint32
+	return func() uint32 { return n.Add(1) }
+}
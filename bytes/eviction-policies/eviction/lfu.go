@@ -2,6 +2,7 @@ package eviction
 
 import (
 	"container/list"
+	"unsafe"
 
 	"github.com/vnscriptkid/sd-keyvalue-store/bytes/eviction-policies/lib"
 )
@@ -87,6 +88,13 @@ func (e *LFUEvictor) bump(en *lib.Entry) {
 	it.node = newBucket.PushFront(it)
 }
 
+func (e *LFUEvictor) Frequency(en *lib.Entry) (int, bool) {
+	if it, ok := e.items[en.Key]; ok {
+		return int(it.freq), true
+	}
+	return 0, true
+}
+
 func (e *LFUEvictor) OnRemove(en *lib.Entry) {
 	it, ok := e.items[en.Key]
 	if !ok {
@@ -143,3 +151,9 @@ func (e *LFUEvictor) Victim() *lib.Entry {
 	}
 	return back.Value.(*lfuItem).en
 }
+
+// NodeBytes: the lfuItem, its list element and the key -> item map slot.
+func (e *LFUEvictor) NodeBytes() int64 {
+	return lib.AllocBytes(int64(unsafe.Sizeof(lfuItem{}))) + listElementBytes +
+		lib.MapSlotBytes(lib.StringHeaderBytes, lib.PointerBytes)
+}
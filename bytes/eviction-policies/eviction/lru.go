@@ -60,3 +60,8 @@ func (e *LRUEvictor) Victim() *lib.Entry {
 	}
 	return back.Value.(*lib.Entry)
 }
+
+// NodeBytes: one list element plus the key -> element map slot.
+func (e *LRUEvictor) NodeBytes() int64 {
+	return listElementBytes + lib.MapSlotBytes(lib.StringHeaderBytes, lib.PointerBytes)
+}
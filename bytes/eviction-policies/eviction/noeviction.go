@@ -0,0 +1,20 @@
+package eviction
+
+import "github.com/vnscriptkid/sd-keyvalue-store/bytes/eviction-policies/lib"
+
+// NoEvictionEvictor never picks a victim, so writes that would exceed the
+// store limits fail with an out of memory error (Redis noeviction).
+type NoEvictionEvictor struct{}
+
+func NewNoEvictionEvictor() *NoEvictionEvictor { return &NoEvictionEvictor{} }
+
+func (e *NoEvictionEvictor) Name() string { return "NOEVICTION" }
+
+func (e *NoEvictionEvictor) OnAdd(en *lib.Entry)    {}
+func (e *NoEvictionEvictor) OnGet(en *lib.Entry)    {}
+func (e *NoEvictionEvictor) OnUpdate(en *lib.Entry) {}
+func (e *NoEvictionEvictor) OnRemove(en *lib.Entry) {}
+
+func (e *NoEvictionEvictor) Victim() *lib.Entry { return nil }
+
+func (e *NoEvictionEvictor) NodeBytes() int64 { return 0 }
@@ -10,3 +10,39 @@ type Evictor interface {
 	OnRemove(e *lib.Entry)
 	Victim() *lib.Entry
 }
+
+// FrequencyReporter is implemented by evictors that track how often a key is
+// accessed (Redis OBJECT FREQ). Wrappers such as VolatileEvictor implement it
+// for any inner policy and return ok=false when the inner one doesn't count.
+type FrequencyReporter interface {
+	Frequency(e *lib.Entry) (freq int, ok bool)
+}
+
+// frequency forwards to inner if it counts accesses.
+func frequency(inner Evictor, en *lib.Entry) (int, bool) {
+	if fr, ok := inner.(FrequencyReporter); ok {
+		return fr.Frequency(en)
+	}
+	return 0, false
+}
+
+// Admitter is implemented by evictors that can refuse a new entry instead of
+// evicting for it. The store asks before evicting victim to make room for
+// candidate; false keeps victim and rejects the write.
+type Admitter interface {
+	Admit(candidate, victim *lib.Entry) bool
+}
+
+// NodeSizer is implemented by evictors that know how many bytes of
+// bookkeeping they keep per tracked entry (used for memory estimation).
+type NodeSizer interface {
+	NodeBytes() int64
+}
+
+// nodeBytes returns ev's per-entry overhead, or 0 if it doesn't report one.
+func nodeBytes(ev Evictor) int64 {
+	if ns, ok := ev.(NodeSizer); ok {
+		return ns.NodeBytes()
+	}
+	return 0
+}
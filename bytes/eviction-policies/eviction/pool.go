@@ -0,0 +1,106 @@
+package eviction
+
+import (
+	"container/list"
+	"sort"
+	"unsafe"
+
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/eviction-policies/lib"
+)
+
+// Redis keeps 16 of the best candidates seen so far across sampling rounds.
+const evictionPoolSize = 16
+
+// listElementBytes is the allocation behind one container/list element.
+var listElementBytes = lib.AllocBytes(int64(unsafe.Sizeof(list.Element{})))
+
+type poolEntry struct {
+	en    *lib.Entry
+	score uint64 // higher = better eviction candidate
+}
+
+// evictionPool holds the best candidates from previous samples, sorted by
+// ascending score, so each Victim call benefits from earlier rounds.
+type evictionPool struct {
+	entries []poolEntry
+}
+
+func (p *evictionPool) offer(en *lib.Entry, score uint64) {
+	for i := range p.entries {
+		if p.entries[i].en.Key == en.Key {
+			p.entries[i] = poolEntry{en: en, score: score}
+			p.sort()
+			return
+		}
+	}
+	if len(p.entries) == evictionPoolSize {
+		if score <= p.entries[0].score {
+			return
+		}
+		p.entries = p.entries[1:] // drop the worst candidate
+	}
+	p.entries = append(p.entries, poolEntry{en: en, score: score})
+	p.sort()
+}
+
+func (p *evictionPool) remove(key string) {
+	for i := range p.entries {
+		if p.entries[i].en.Key == key {
+			p.entries = append(p.entries[:i], p.entries[i+1:]...)
+			return
+		}
+	}
+}
+
+// rescore refreshes scores that went stale because the keys were accessed
+// since they entered the pool.
+func (p *evictionPool) rescore(score func(*lib.Entry) uint64) {
+	for i := range p.entries {
+		p.entries[i].score = score(p.entries[i].en)
+	}
+	p.sort()
+}
+
+func (p *evictionPool) sort() {
+	sort.Slice(p.entries, func(i, j int) bool { return p.entries[i].score < p.entries[j].score })
+}
+
+func (p *evictionPool) best() *lib.Entry {
+	if len(p.entries) == 0 {
+		return nil
+	}
+	return p.entries[len(p.entries)-1].en
+}
+
+// sampledPolicy is the Redis-style victim selection shared by the
+// approximated evictors: sample a few keys, keep the best in a pool.
+type sampledPolicy struct {
+	keys    lib.KeySampler
+	pool    evictionPool
+	samples int
+	score   func(*lib.Entry) uint64
+}
+
+func newSampledPolicy(samples int, score func(*lib.Entry) uint64) sampledPolicy {
+	if samples <= 0 {
+		samples = 5 // Redis maxmemory-samples default
+	}
+	return sampledPolicy{keys: lib.NewKeySampler(), samples: samples, score: score}
+}
+
+func (p *sampledPolicy) remove(key string) {
+	p.keys.Remove(key)
+	p.pool.remove(key)
+}
+
+func (p *sampledPolicy) victim() *lib.Entry {
+	if p.keys.Len() == 0 {
+		return nil
+	}
+	p.pool.rescore(p.score)
+	for i := 0; i < p.samples; i++ {
+		en := p.keys.Sample()
+		p.pool.offer(en, p.score(en))
+	}
+	return p.pool.best()
+}
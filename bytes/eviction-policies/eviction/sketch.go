@@ -0,0 +1,77 @@
+package eviction
+
+import "hash/fnv"
+
+const (
+	sketchDepth   = 4
+	sketchMaxHits = 15 // 4-bit counters, as in TinyLFU
+)
+
+// countMinSketch estimates access frequency of keys in constant memory.
+// Counters are halved every resetAt additions so old popularity fades.
+type countMinSketch struct {
+	rows      [sketchDepth][]uint8
+	mask      uint32
+	additions int
+	resetAt   int
+}
+
+func newCountMinSketch(capacity int) *countMinSketch {
+	width := 16
+	for width < capacity {
+		width <<= 1
+	}
+	s := &countMinSketch{
+		mask:    uint32(width - 1),
+		resetAt: 10 * width,
+	}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, width)
+	}
+	return s
+}
+
+// indexes derives one column per row from a single 64-bit hash (double hashing).
+func (s *countMinSketch) indexes(key string) [sketchDepth]uint32 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	sum := h.Sum64()
+	h1, h2 := uint32(sum), uint32(sum>>32)
+
+	var out [sketchDepth]uint32
+	for i := range out {
+		out[i] = (h1 + uint32(i)*h2) & s.mask
+	}
+	return out
+}
+
+func (s *countMinSketch) increment(key string) {
+	for i, col := range s.indexes(key) {
+		if s.rows[i][col] < sketchMaxHits {
+			s.rows[i][col]++
+		}
+	}
+	s.additions++
+	if s.additions >= s.resetAt {
+		s.reset()
+	}
+}
+
+func (s *countMinSketch) estimate(key string) uint8 {
+	min := uint8(sketchMaxHits)
+	for i, col := range s.indexes(key) {
+		if v := s.rows[i][col]; v < min {
+			min = v
+		}
+	}
+	return min
+}
+
+func (s *countMinSketch) reset() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] >>= 1
+		}
+	}
+	s.additions /= 2
+}
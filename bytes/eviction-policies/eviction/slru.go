@@ -0,0 +1,122 @@
+package eviction
+
+import (
+	"container/list"
+	"unsafe"
+
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/eviction-policies/lib"
+)
+
+// Share of a segmented LRU reserved for keys that were hit at least twice.
+const protectedRatio = 0.8
+
+type slruItem struct {
+	en        *lib.Entry
+	protected bool
+	node      *list.Element // node inside probation or protected, Value=*slruItem
+}
+
+// segmentedLRU splits LRU into a probation segment for new keys and a
+// protected segment for keys hit again, so a one-off scan only churns probation.
+type segmentedLRU struct {
+	probation *list.List // front=MRU, back=LRU
+	protected *list.List // front=MRU, back=LRU
+	items     map[string]*slruItem
+}
+
+func newSegmentedLRU() segmentedLRU {
+	return segmentedLRU{
+		probation: list.New(),
+		protected: list.New(),
+		items:     make(map[string]*slruItem),
+	}
+}
+
+func (s *segmentedLRU) len() int { return len(s.items) }
+
+func (s *segmentedLRU) contains(key string) bool {
+	_, ok := s.items[key]
+	return ok
+}
+
+func (s *segmentedLRU) add(en *lib.Entry) {
+	if it, ok := s.items[en.Key]; ok {
+		it.en = en
+		s.touch(en)
+		return
+	}
+	it := &slruItem{en: en}
+	it.node = s.probation.PushFront(it)
+	s.items[en.Key] = it
+}
+
+func (s *segmentedLRU) touch(en *lib.Entry) {
+	it, ok := s.items[en.Key]
+	if !ok {
+		s.add(en)
+		return
+	}
+	it.en = en // keep pointer fresh
+	if it.protected {
+		s.protected.MoveToFront(it.node)
+		return
+	}
+
+	// promote; demote protected LRU back to probation if it grew too large
+	s.probation.Remove(it.node)
+	it.protected = true
+	it.node = s.protected.PushFront(it)
+	if s.protected.Len() > int(protectedRatio*float64(len(s.items))) {
+		back := s.protected.Back().Value.(*slruItem)
+		s.protected.Remove(back.node)
+		back.protected = false
+		back.node = s.probation.PushFront(back)
+	}
+}
+
+func (s *segmentedLRU) remove(key string) {
+	it, ok := s.items[key]
+	if !ok {
+		return
+	}
+	if it.protected {
+		s.protected.Remove(it.node)
+	} else {
+		s.probation.Remove(it.node)
+	}
+	delete(s.items, key)
+}
+
+func (s *segmentedLRU) victim() *lib.Entry {
+	if back := s.probation.Back(); back != nil {
+		return back.Value.(*slruItem).en
+	}
+	if back := s.protected.Back(); back != nil {
+		return back.Value.(*slruItem).en
+	}
+	return nil
+}
+
+// SLRUEvictor is a segmented LRU: probation keys are evicted before any
+// protected key, which makes it resistant to one-off scans.
+type SLRUEvictor struct {
+	segmentedLRU
+}
+
+func NewSLRUEvictor() *SLRUEvictor {
+	return &SLRUEvictor{segmentedLRU: newSegmentedLRU()}
+}
+
+func (e *SLRUEvictor) Name() string { return "SLRU" }
+
+func (e *SLRUEvictor) OnAdd(en *lib.Entry)    { e.add(en) }
+func (e *SLRUEvictor) OnGet(en *lib.Entry)    { e.touch(en) }
+func (e *SLRUEvictor) OnUpdate(en *lib.Entry) { e.touch(en) }
+func (e *SLRUEvictor) OnRemove(en *lib.Entry) { e.remove(en.Key) }
+func (e *SLRUEvictor) Victim() *lib.Entry     { return e.victim() }
+
+// NodeBytes: the slruItem, its list element and the key -> item map slot.
+func (e *SLRUEvictor) NodeBytes() int64 { return slruNodeBytes }
+
+var slruNodeBytes = lib.AllocBytes(int64(unsafe.Sizeof(slruItem{}))) + listElementBytes +
+	lib.MapSlotBytes(lib.StringHeaderBytes, lib.PointerBytes)
@@ -0,0 +1,102 @@
+package eviction
+
+import (
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/eviction-policies/expiry"
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/eviction-policies/lib"
+)
+
+// VolatileEvictor restricts an inner policy to keys that carry a TTL
+// (Redis volatile-lru / volatile-lfu / volatile-random). Keys without a
+// deadline are never handed to the inner evictor, so they are never victims.
+type VolatileEvictor struct {
+	inner   Evictor
+	tracked map[string]bool // keys currently known to inner
+}
+
+func NewVolatileEvictor(inner Evictor) *VolatileEvictor {
+	return &VolatileEvictor{
+		inner:   inner,
+		tracked: make(map[string]bool),
+	}
+}
+
+func NewVolatileLRUEvictor() *VolatileEvictor    { return NewVolatileEvictor(NewLRUEvictor()) }
+func NewVolatileLFUEvictor() *VolatileEvictor    { return NewVolatileEvictor(NewLFUEvictor()) }
+func NewVolatileRandomEvictor() *VolatileEvictor { return NewVolatileEvictor(NewRandomEvictor()) }
+
+func (e *VolatileEvictor) Name() string { return "VOLATILE-" + e.inner.Name() }
+
+func (e *VolatileEvictor) OnAdd(en *lib.Entry) {
+	if en.ExpireAt == 0 {
+		return
+	}
+	e.tracked[en.Key] = true
+	e.inner.OnAdd(en)
+}
+
+func (e *VolatileEvictor) OnGet(en *lib.Entry) {
+	if e.tracked[en.Key] {
+		e.inner.OnGet(en)
+	}
+}
+
+// OnUpdate also fires when a key gains or loses its TTL, which moves it
+// in or out of the candidate set.
+func (e *VolatileEvictor) OnUpdate(en *lib.Entry) {
+	switch {
+	case en.ExpireAt > 0 && e.tracked[en.Key]:
+		e.inner.OnUpdate(en)
+	case en.ExpireAt > 0:
+		e.OnAdd(en)
+	case e.tracked[en.Key]:
+		e.OnRemove(en)
+	}
+}
+
+func (e *VolatileEvictor) OnRemove(en *lib.Entry) {
+	if !e.tracked[en.Key] {
+		return
+	}
+	delete(e.tracked, en.Key)
+	e.inner.OnRemove(en)
+}
+
+func (e *VolatileEvictor) Victim() *lib.Entry { return e.inner.Victim() }
+
+// Frequency is the inner policy's count (volatile-lfu); keys without a TTL
+// are unknown to it.
+func (e *VolatileEvictor) Frequency(en *lib.Entry) (int, bool) { return frequency(e.inner, en) }
+
+// VolatileTTLEvictor evicts the key with the soonest deadline (Redis volatile-ttl).
+type VolatileTTLEvictor struct {
+	deadlines *expiry.HeapExpirer
+}
+
+func NewVolatileTTLEvictor() *VolatileTTLEvictor {
+	return &VolatileTTLEvictor{deadlines: expiry.NewHeapExpirer()}
+}
+
+func (e *VolatileTTLEvictor) Name() string { return "VOLATILE-TTL" }
+
+func (e *VolatileTTLEvictor) OnAdd(en *lib.Entry) { e.OnUpdate(en) }
+func (e *VolatileTTLEvictor) OnGet(en *lib.Entry) {}
+
+func (e *VolatileTTLEvictor) OnUpdate(en *lib.Entry) {
+	if en.ExpireAt == 0 {
+		e.deadlines.Untrack(en)
+		return
+	}
+	e.deadlines.Track(en)
+}
+
+func (e *VolatileTTLEvictor) OnRemove(en *lib.Entry) { e.deadlines.Untrack(en) }
+
+func (e *VolatileTTLEvictor) Victim() *lib.Entry { return e.deadlines.Next() }
+
+// NodeBytes is the inner policy's cost plus the tracked map slot. Keys
+// without a TTL are charged too, which slightly overestimates.
+func (e *VolatileEvictor) NodeBytes() int64 {
+	return nodeBytes(e.inner) + lib.MapSlotBytes(lib.StringHeaderBytes, 1)
+}
+
+func (e *VolatileTTLEvictor) NodeBytes() int64 { return e.deadlines.NodeBytes() }
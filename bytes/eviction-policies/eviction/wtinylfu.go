@@ -0,0 +1,115 @@
+package eviction
+
+import (
+	"container/list"
+
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/eviction-policies/lib"
+)
+
+// WTinyLFUEvictor is the Caffeine policy: new keys land in a small LRU
+// window (1% of capacity); keys leaving the window must beat the main
+// SLRU's victim on estimated frequency (count-min sketch) to stay.
+// Bursts get a chance in the window, scans can't flush the hot set.
+type WTinyLFUEvictor struct {
+	capacity  int
+	windowCap int
+
+	window *list.List               // front=MRU, back=LRU
+	nodes  map[string]*list.Element // window key -> node (Value=*lib.Entry)
+	main   segmentedLRU
+	sketch *countMinSketch
+}
+
+// NewWTinyLFUEvictor sizes the window and the sketch for capacity keys,
+// typically the maxKeys given to the store.
+func NewWTinyLFUEvictor(capacity int) *WTinyLFUEvictor {
+	windowCap := capacity / 100
+	if windowCap < 1 {
+		windowCap = 1
+	}
+	return &WTinyLFUEvictor{
+		capacity:  capacity,
+		windowCap: windowCap,
+		window:    list.New(),
+		nodes:     make(map[string]*list.Element),
+		main:      newSegmentedLRU(),
+		sketch:    newCountMinSketch(capacity),
+	}
+}
+
+func (e *WTinyLFUEvictor) Name() string { return "W-TINYLFU" }
+
+func (e *WTinyLFUEvictor) OnAdd(en *lib.Entry) {
+	if e.nodes[en.Key] != nil || e.main.contains(en.Key) {
+		e.OnUpdate(en)
+		return
+	}
+	e.sketch.increment(en.Key)
+	e.nodes[en.Key] = e.window.PushFront(en)
+
+	// While there is room, window overflow moves to main without a contest.
+	if e.window.Len() > e.windowCap && e.window.Len()+e.main.len() <= e.capacity {
+		e.moveToMain(e.window.Back())
+	}
+}
+
+func (e *WTinyLFUEvictor) OnGet(en *lib.Entry)    { e.touch(en) }
+func (e *WTinyLFUEvictor) OnUpdate(en *lib.Entry) { e.touch(en) }
+
+func (e *WTinyLFUEvictor) touch(en *lib.Entry) {
+	e.sketch.increment(en.Key)
+	if node, ok := e.nodes[en.Key]; ok {
+		node.Value = en // keep pointer fresh
+		e.window.MoveToFront(node)
+		return
+	}
+	if e.main.contains(en.Key) {
+		e.main.touch(en)
+		return
+	}
+	// desynced: re-add
+	e.OnAdd(en)
+}
+
+func (e *WTinyLFUEvictor) OnRemove(en *lib.Entry) {
+	if node, ok := e.nodes[en.Key]; ok {
+		e.window.Remove(node)
+		delete(e.nodes, en.Key)
+		return
+	}
+	e.main.remove(en.Key)
+}
+
+func (e *WTinyLFUEvictor) moveToMain(node *list.Element) {
+	en := node.Value.(*lib.Entry)
+	e.window.Remove(node)
+	delete(e.nodes, en.Key)
+	e.main.add(en)
+}
+
+func (e *WTinyLFUEvictor) Victim() *lib.Entry {
+	mainVictim := e.main.victim()
+
+	back := e.window.Back()
+	if back == nil {
+		return mainVictim
+	}
+	candidate := back.Value.(*lib.Entry)
+	if mainVictim == nil {
+		return candidate
+	}
+	if e.window.Len() <= e.windowCap {
+		return mainVictim
+	}
+
+	// Window is over its share: its LRU competes with main's LRU.
+	if e.sketch.estimate(candidate.Key) > e.sketch.estimate(mainVictim.Key) {
+		e.moveToMain(back)
+		return mainVictim
+	}
+	return candidate
+}
+
+// NodeBytes uses the main space cost (window nodes are cheaper); the
+// sketch is a fixed cost and is not charged per entry.
+func (e *WTinyLFUEvictor) NodeBytes() int64 { return slruNodeBytes }
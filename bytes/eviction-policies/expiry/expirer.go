@@ -0,0 +1,17 @@
+package expiry
+
+import "github.com/vnscriptkid/sd-keyvalue-store/bytes/eviction-policies/lib"
+
+// Expirer indexes entries that carry a deadline (lib.Entry.ExpireAt) so the
+// store can reclaim them without waiting for a Get.
+type Expirer interface {
+	Name() string
+	Track(e *lib.Entry)   // entry got a deadline, or its deadline changed
+	Untrack(e *lib.Entry) // entry lost its deadline or left the store
+	Len() int
+
+	// Expired returns up to limit entries whose deadline is <= now (unix nanos).
+	// The store removes them and calls Untrack, so Untrack must tolerate
+	// entries the expirer already dropped.
+	Expired(now int64, limit int) []*lib.Entry
+}
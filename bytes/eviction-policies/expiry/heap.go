@@ -0,0 +1,100 @@
+package expiry
+
+import (
+	"container/heap"
+	"unsafe"
+
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/eviction-policies/lib"
+)
+
+type heapItem struct {
+	en    *lib.Entry
+	index int // position inside deadlineHeap
+}
+
+type deadlineHeap []*heapItem
+
+func (h deadlineHeap) Len() int           { return len(h) }
+func (h deadlineHeap) Less(i, j int) bool { return h[i].en.ExpireAt < h[j].en.ExpireAt }
+func (h deadlineHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+func (h *deadlineHeap) Push(x any) {
+	it := x.(*heapItem)
+	it.index = len(*h)
+	*h = append(*h, it)
+}
+func (h *deadlineHeap) Pop() any {
+	old := *h
+	n := len(old)
+	it := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	it.index = -1
+	return it
+}
+
+// HeapExpirer keeps a min-heap ordered by deadline, so keys are reclaimed
+// exactly when they are due at the cost of O(log n) per TTL change.
+type HeapExpirer struct {
+	h     deadlineHeap
+	items map[string]*heapItem // key -> item
+}
+
+func NewHeapExpirer() *HeapExpirer {
+	return &HeapExpirer{items: make(map[string]*heapItem)}
+}
+
+func (x *HeapExpirer) Name() string { return "HEAP" }
+
+func (x *HeapExpirer) Len() int { return len(x.h) }
+
+func (x *HeapExpirer) Track(en *lib.Entry) {
+	if it, ok := x.items[en.Key]; ok {
+		it.en = en // keep pointer fresh
+		heap.Fix(&x.h, it.index)
+		return
+	}
+	it := &heapItem{en: en}
+	heap.Push(&x.h, it)
+	x.items[en.Key] = it
+}
+
+func (x *HeapExpirer) Untrack(en *lib.Entry) {
+	it, ok := x.items[en.Key]
+	if !ok {
+		return
+	}
+	heap.Remove(&x.h, it.index)
+	delete(x.items, en.Key)
+}
+
+// Next returns the entry with the soonest deadline, or nil if none is tracked.
+func (x *HeapExpirer) Next() *lib.Entry {
+	if len(x.h) == 0 {
+		return nil
+	}
+	return x.h[0].en
+}
+
+func (x *HeapExpirer) Expired(now int64, limit int) []*lib.Entry {
+	var out []*lib.Entry
+	for len(x.h) > 0 && len(out) < limit {
+		top := x.h[0]
+		if !top.en.Expired(now) {
+			break
+		}
+		heap.Pop(&x.h)
+		delete(x.items, top.en.Key)
+		out = append(out, top.en)
+	}
+	return out
+}
+
+// NodeBytes: the heapItem, its slot in the heap and the key -> item map slot.
+func (x *HeapExpirer) NodeBytes() int64 {
+	return lib.AllocBytes(int64(unsafe.Sizeof(heapItem{}))) + lib.PointerBytes +
+		lib.MapSlotBytes(lib.StringHeaderBytes, lib.PointerBytes)
+}
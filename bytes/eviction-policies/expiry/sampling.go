@@ -0,0 +1,55 @@
+package expiry
+
+import (
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/eviction-policies/lib"
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/rediscompat"
+)
+
+// SamplingExpirer reclaims expired keys probabilistically. Tracking is O(1)
+// and a cycle costs a bounded amount of work however large the keyspace is,
+// but some expired keys linger in memory until sampled or touched.
+type SamplingExpirer struct {
+	keys lib.KeySampler
+}
+
+func NewSamplingExpirer() *SamplingExpirer {
+	return &SamplingExpirer{keys: lib.NewKeySampler()}
+}
+
+func (x *SamplingExpirer) Name() string { return "SAMPLING" }
+
+func (x *SamplingExpirer) Len() int { return x.keys.Len() }
+
+func (x *SamplingExpirer) Track(en *lib.Entry)   { x.keys.Add(en) }
+func (x *SamplingExpirer) Untrack(en *lib.Entry) { x.keys.Remove(en.Key) }
+
+// Expired samples keys with the same rediscompat tuning as bytes/kv.
+func (x *SamplingExpirer) Expired(now int64, limit int) []*lib.Entry {
+	var out []*lib.Entry
+	seen := make(map[string]bool)
+
+	for round := 0; round < rediscompat.ExpireMaxRounds && x.keys.Len() > 0; round++ {
+		sampled, expired := 0, 0
+		for sampled < rediscompat.ExpireSampleSize && sampled < x.keys.Len() {
+			en := x.keys.Sample()
+			sampled++
+			if !en.Expired(now) {
+				continue
+			}
+			expired++
+			if !seen[en.Key] {
+				seen[en.Key] = true
+				out = append(out, en)
+				if len(out) == limit {
+					return out
+				}
+			}
+		}
+		if expired*100 <= sampled*rediscompat.ExpireRepeatPercent {
+			break
+		}
+	}
+	return out
+}
+
+func (x *SamplingExpirer) NodeBytes() int64 { return lib.KeySamplerNodeBytes }
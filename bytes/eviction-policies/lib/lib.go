@@ -2,6 +2,42 @@ package lib
 
 type Entry struct {
 	Key   string
-	Value []byte
+	Type  Type
+	Value []byte // payload of TypeString
 	Bytes int64
+
+	// Object is the payload of the other types: *List, Set, Hash or *ZSet.
+	Object any
+
+	// ExpireAt is the absolute deadline in unix nanoseconds (0 = no expiry).
+	ExpireAt int64
+
+	// Version changes on every write to the value; the store hands out
+	// versions from one counter, so a recreated key never reuses one.
+	Version uint64
+
+	// AccessClock is a coarse last-access timestamp stamped by sampling
+	// evictors, so reads update the entry instead of evictor structures.
+	AccessClock uint32
+
+	// LFU metadata for eviction.ApproxLFUEvictor: a logarithmic 8-bit
+	// access counter and the minute clock of its last decay.
+	LFUCounter  uint8
+	LFUDecrTime uint16
+}
+
+// Expired reports whether the entry has a deadline at or before now (unix nanos).
+func (e *Entry) Expired(now int64) bool {
+	return e.ExpireAt > 0 && now >= e.ExpireAt
+}
+
+// LogicalBytes is the user-visible size of the entry: key + value, where
+// the value of a collection is the sum of its elements (scores count 8).
+// Collections keep that sum as they change, so this is O(1).
+func (e *Entry) LogicalBytes() int64 {
+	n := int64(len(e.Key))
+	if c, ok := e.Object.(Collection); ok {
+		return n + c.Bytes()
+	}
+	return n + int64(len(e.Value))
 }
@@ -0,0 +1,68 @@
+package lib
+
+import (
+	"math/rand"
+	"time"
+)
+
+// KeySampler keeps entries in a slice so adds, removes and uniform random
+// samples are all O(1): a removed key is swapped with the last one.
+type KeySampler struct {
+	rnd  *rand.Rand
+	keys []string
+	idx  map[string]int    // key -> index in keys
+	ptr  map[string]*Entry // key -> *Entry (so samples return the pointer)
+}
+
+// KeySamplerNodeBytes is the per-key bookkeeping of a KeySampler: a string
+// in keys plus the idx and ptr map slots.
+var KeySamplerNodeBytes = StringHeaderBytes +
+	MapSlotBytes(StringHeaderBytes, 8) +
+	MapSlotBytes(StringHeaderBytes, PointerBytes)
+
+func NewKeySampler() KeySampler {
+	return KeySampler{
+		rnd: rand.New(rand.NewSource(time.Now().UnixNano())),
+		idx: make(map[string]int),
+		ptr: make(map[string]*Entry),
+	}
+}
+
+// Add tracks e, or refreshes the pointer kept for a key already tracked.
+func (s *KeySampler) Add(e *Entry) {
+	if _, ok := s.idx[e.Key]; ok {
+		s.ptr[e.Key] = e
+		return
+	}
+	s.idx[e.Key] = len(s.keys)
+	s.keys = append(s.keys, e.Key)
+	s.ptr[e.Key] = e
+}
+
+func (s *KeySampler) Remove(key string) {
+	i, ok := s.idx[key]
+	if !ok {
+		return
+	}
+	last := len(s.keys) - 1
+	lastKey := s.keys[last]
+
+	// swap-delete
+	s.keys[i] = lastKey
+	s.idx[lastKey] = i
+	s.keys = s.keys[:last]
+
+	delete(s.idx, key)
+	delete(s.ptr, key)
+}
+
+func (s *KeySampler) Len() int { return len(s.keys) }
+
+// Sample returns a tracked entry picked uniformly at random, or nil if
+// there is none.
+func (s *KeySampler) Sample() *Entry {
+	if len(s.keys) == 0 {
+		return nil
+	}
+	return s.ptr[s.keys[s.rnd.Intn(len(s.keys))]]
+}
@@ -0,0 +1,35 @@
+package lib
+
+import "unsafe"
+
+// Rough Go runtime costs used by memory estimators. They follow the 64-bit
+// layout and are meant to be in the right ballpark, not byte exact.
+const (
+	StringHeaderBytes = int64(unsafe.Sizeof(""))
+	SliceHeaderBytes  = int64(unsafe.Sizeof([]byte(nil)))
+	PointerBytes      = int64(unsafe.Sizeof(uintptr(0)))
+)
+
+// AllocBytes rounds a heap allocation of n bytes up to its size class
+// (8-byte steps for tiny objects, 16-byte steps up to 1KB, then pages / 8).
+func AllocBytes(n int64) int64 {
+	switch {
+	case n <= 0:
+		return 0
+	case n <= 16:
+		return (n + 7) &^ 7
+	case n <= 1024:
+		return (n + 15) &^ 15
+	default:
+		return (n + 1023) &^ 1023
+	}
+}
+
+// MapSlotBytes estimates one map slot holding a key and a value of the given
+// widths: one control byte plus the slot, spread over a 7/8 load factor.
+func MapSlotBytes(keyBytes, valBytes int64) int64 {
+	return (keyBytes + valBytes + 1) * 8 / 7
+}
+
+// EntryStructBytes is the allocation behind one *Entry.
+var EntryStructBytes = AllocBytes(int64(unsafe.Sizeof(Entry{})))
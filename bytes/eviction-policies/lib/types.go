@@ -0,0 +1,344 @@
+package lib
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"unsafe"
+)
+
+// Type is the kind of value an Entry holds (readme Version 1, approach 1:
+// keep a type variable next to the payload).
+type Type uint8
+
+const (
+	TypeString Type = iota
+	TypeList
+	TypeSet
+	TypeHash
+	TypeZSet
+)
+
+func (t Type) String() string {
+	switch t {
+	case TypeString:
+		return "string"
+	case TypeList:
+		return "list"
+	case TypeSet:
+		return "set"
+	case TypeHash:
+		return "hash"
+	case TypeZSet:
+		return "zset"
+	default:
+		return "unknown"
+	}
+}
+
+// NewObject returns the empty payload for t (nil for TypeString).
+func NewObject(t Type) any {
+	switch t {
+	case TypeList:
+		return &List{}
+	case TypeSet:
+		return &Set{m: make(map[string]struct{})}
+	case TypeHash:
+		return &Hash{m: make(map[string][]byte)}
+	case TypeZSet:
+		return &ZSet{scores: make(map[string]float64)}
+	default:
+		return nil
+	}
+}
+
+// Len is the number of elements of a collection entry (0 for strings).
+func (e *Entry) Len() int {
+	if c, ok := e.Object.(Collection); ok {
+		return c.Len()
+	}
+	return 0
+}
+
+// NormalizeRange turns Redis-style inclusive indexes (negative = from the
+// end) into a [lo, hi) slice range over n elements. ok is false when empty.
+func NormalizeRange(start, stop, n int) (lo, hi int, ok bool) {
+	if start < 0 {
+		start += n
+	}
+	if stop < 0 {
+		stop += n
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= n {
+		stop = n - 1
+	}
+	if start > stop || start >= n {
+		return 0, 0, false
+	}
+	return start, stop + 1, true
+}
+
+// ErrNaNScore is returned for a sorted set score that is NaN, which has no
+// place in the order.
+var ErrNaNScore = errors.New("ERR score is not a valid float")
+
+// Collection is implemented by *List, *Set, *Hash and *ZSet. Each keeps the
+// logical size of its elements (Bytes) and an estimate of their heap cost
+// (HeapBytes) up to date as elements come and go, so charging an entry
+// after a write doesn't walk the collection.
+type Collection interface {
+	Len() int
+	Bytes() int64
+	HeapBytes() int64
+}
+
+// List is a list of byte strings (front = index 0), kept in a ring buffer
+// so pushes and pops at either end are O(1).
+type List struct {
+	buf   [][]byte // len is 0 or a power of two
+	head  int      // index in buf of the front element
+	n     int
+	bytes int64
+	heap  int64
+}
+
+func (l *List) Len() int { return l.n }
+
+// At returns the element at index i, 0 <= i < Len().
+func (l *List) At(i int) []byte { return l.buf[(l.head+i)&(len(l.buf)-1)] }
+
+func (l *List) PushFront(v []byte) {
+	l.grow()
+	l.head = (l.head - 1) & (len(l.buf) - 1)
+	l.buf[l.head] = v
+	l.n++
+	l.account(v, 1)
+}
+
+func (l *List) PushBack(v []byte) {
+	l.grow()
+	l.buf[(l.head+l.n)&(len(l.buf)-1)] = v
+	l.n++
+	l.account(v, 1)
+}
+
+func (l *List) PopFront() ([]byte, bool) {
+	if l.n == 0 {
+		return nil, false
+	}
+	v := l.buf[l.head]
+	l.buf[l.head] = nil
+	l.head = (l.head + 1) & (len(l.buf) - 1)
+	l.n--
+	l.account(v, -1)
+	return v, true
+}
+
+// grow doubles the ring when it is full.
+func (l *List) grow() {
+	if l.n < len(l.buf) {
+		return
+	}
+	buf := make([][]byte, max(8, 2*len(l.buf)))
+	for i := 0; i < l.n; i++ {
+		buf[i] = l.At(i)
+	}
+	l.buf, l.head = buf, 0
+}
+
+func (l *List) account(v []byte, sign int64) {
+	l.bytes += sign * int64(len(v))
+	l.heap += sign * AllocBytes(int64(cap(v)))
+}
+
+func (l *List) Bytes() int64 { return l.bytes }
+
+func (l *List) HeapBytes() int64 {
+	return AllocBytes(int64(unsafe.Sizeof(List{}))) + AllocBytes(int64(len(l.buf))*SliceHeaderBytes) + l.heap
+}
+
+// Set is a set of strings.
+type Set struct {
+	m     map[string]struct{}
+	bytes int64
+	heap  int64
+}
+
+// Add reports whether member is new.
+func (s *Set) Add(member string) bool {
+	if _, ok := s.m[member]; ok {
+		return false
+	}
+	s.m[member] = struct{}{}
+	s.account(member, 1)
+	return true
+}
+
+// Remove reports whether member was present.
+func (s *Set) Remove(member string) bool {
+	if _, ok := s.m[member]; !ok {
+		return false
+	}
+	delete(s.m, member)
+	s.account(member, -1)
+	return true
+}
+
+func (s *Set) Has(member string) bool {
+	_, ok := s.m[member]
+	return ok
+}
+
+func (s *Set) Len() int { return len(s.m) }
+
+// Members returns the members in no particular order.
+func (s *Set) Members() []string {
+	out := make([]string, 0, len(s.m))
+	for m := range s.m {
+		out = append(out, m)
+	}
+	return out
+}
+
+func (s *Set) account(member string, sign int64) {
+	s.bytes += sign * int64(len(member))
+	s.heap += sign * (MapSlotBytes(StringHeaderBytes, 0) + AllocBytes(int64(len(member))))
+}
+
+func (s *Set) Bytes() int64     { return s.bytes }
+func (s *Set) HeapBytes() int64 { return s.heap }
+
+// Hash maps fields to values.
+type Hash struct {
+	m     map[string][]byte
+	bytes int64
+	heap  int64
+}
+
+// Set sets field to v and reports whether field is new.
+func (h *Hash) Set(field string, v []byte) bool {
+	old, exists := h.m[field]
+	if exists {
+		h.account(field, old, -1)
+	}
+	h.m[field] = v
+	h.account(field, v, 1)
+	return !exists
+}
+
+func (h *Hash) Get(field string) ([]byte, bool) {
+	v, ok := h.m[field]
+	return v, ok
+}
+
+// Del reports whether field was present.
+func (h *Hash) Del(field string) bool {
+	v, ok := h.m[field]
+	if !ok {
+		return false
+	}
+	delete(h.m, field)
+	h.account(field, v, -1)
+	return true
+}
+
+func (h *Hash) Len() int { return len(h.m) }
+
+// Range calls fn for every field, in no particular order.
+func (h *Hash) Range(fn func(field string, v []byte)) {
+	for f, v := range h.m {
+		fn(f, v)
+	}
+}
+
+func (h *Hash) account(field string, v []byte, sign int64) {
+	h.bytes += sign * int64(len(field)+len(v))
+	h.heap += sign * (MapSlotBytes(StringHeaderBytes, SliceHeaderBytes) +
+		AllocBytes(int64(len(field))) + AllocBytes(int64(cap(v))))
+}
+
+func (h *Hash) Bytes() int64     { return h.bytes }
+func (h *Hash) HeapBytes() int64 { return h.heap }
+
+// ZMember is a sorted set member with its score.
+type ZMember struct {
+	Member string
+	Score  float64
+}
+
+// ZSet keeps members ordered by (score, member). Redis uses a skiplist;
+// a sorted slice is enough here (O(n) updates, O(log n) rank lookups).
+type ZSet struct {
+	scores map[string]float64
+	sorted []ZMember
+	bytes  int64
+	heap   int64
+}
+
+func zless(a, b ZMember) bool {
+	if a.Score != b.Score {
+		return a.Score < b.Score
+	}
+	return a.Member < b.Member
+}
+
+func (z *ZSet) search(m ZMember) int {
+	return sort.Search(len(z.sorted), func(i int) bool { return !zless(z.sorted[i], m) })
+}
+
+// Add sets member's score and reports whether member is new. A NaN score is
+// ErrNaNScore: it compares false with everything and would break the order.
+func (z *ZSet) Add(member string, score float64) (bool, error) {
+	if math.IsNaN(score) {
+		return false, ErrNaNScore
+	}
+	old, exists := z.scores[member]
+	if exists {
+		if old == score {
+			return false, nil
+		}
+		i := z.search(ZMember{Member: member, Score: old})
+		z.sorted = append(z.sorted[:i], z.sorted[i+1:]...)
+	} else {
+		z.bytes += int64(len(member)) + 8
+		z.heap += MapSlotBytes(StringHeaderBytes, 8) + int64(unsafe.Sizeof(ZMember{})) + AllocBytes(int64(len(member)))
+	}
+	z.scores[member] = score
+
+	m := ZMember{Member: member, Score: score}
+	i := z.search(m)
+	z.sorted = append(z.sorted, ZMember{})
+	copy(z.sorted[i+1:], z.sorted[i:])
+	z.sorted[i] = m
+	return !exists, nil
+}
+
+func (z *ZSet) Len() int { return len(z.sorted) }
+
+// Rank is the 0-based position of member in ascending order.
+func (z *ZSet) Rank(member string) (int, bool) {
+	score, ok := z.scores[member]
+	if !ok {
+		return 0, false
+	}
+	return z.search(ZMember{Member: member, Score: score}), true
+}
+
+// Range returns members between the inclusive indexes start and stop.
+func (z *ZSet) Range(start, stop int) []ZMember {
+	lo, hi, ok := NormalizeRange(start, stop, len(z.sorted))
+	if !ok {
+		return nil
+	}
+	return append([]ZMember(nil), z.sorted[lo:hi]...)
+}
+
+// Bytes counts each member plus 8 bytes for its score.
+func (z *ZSet) Bytes() int64 { return z.bytes }
+
+// HeapBytes counts the member strings once: the score map and the sorted
+// slice share them.
+func (z *ZSet) HeapBytes() int64 { return z.heap }
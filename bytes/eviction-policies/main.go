@@ -1,9 +1,18 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"math/rand"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/vnscriptkid/sd-keyvalue-store/bytes/eviction-policies/eviction"
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/eviction-policies/expiry"
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/eviction-policies/lib"
 	"github.com/vnscriptkid/sd-keyvalue-store/bytes/eviction-policies/store"
 )
 
@@ -17,6 +26,14 @@ func mustSet(s *store.Store, k, v string) {
 	}
 }
 
+func printEvicted(ev store.EvictEvent) {
+	val := string(ev.Value)
+	if c, ok := ev.Object.(lib.Collection); ok {
+		val = fmt.Sprintf("%s of %d", ev.Type, c.Len())
+	}
+	fmt.Printf("  evicted %s=%s (%s)\n", ev.Key, val, ev.Reason)
+}
+
 func demo(policy eviction.Evictor) {
 	fmt.Printf("\n===== Policy: %s =====\n", policy.Name())
 
@@ -25,6 +42,7 @@ func demo(policy eviction.Evictor) {
 	maxBytes := int64(20)
 
 	s := store.NewStore(maxKeys, maxBytes, policy)
+	s.OnEvict(printEvicted)
 
 	// Fill
 	mustSet(s, "a", "1111") // ~5 bytes
@@ -39,8 +57,9 @@ func demo(policy eviction.Evictor) {
 	// This will exceed maxBytes (~15 + 7 = 22) and exceed maxKeys (4 > 3)
 	mustSet(s, "d", "444444") // ~7
 
-	keys, bytes, pol := s.Stats()
-	fmt.Printf("Stats: keys=%d bytes=%d policy=%s\n", keys, bytes, pol)
+	st := s.Stats()
+	fmt.Printf("Stats: keys=%d bytes=%d policy=%s hits=%d misses=%d evictions(maxKeys=%d maxBytes=%d) bytesEvicted=%d\n",
+		st.Keys, st.Bytes, st.Policy, st.Hits, st.Misses, st.EvictionsMaxKeys, st.EvictionsMaxBytes, st.BytesEvicted)
 
 	// Show what remains (Get() also touches; for demo simplicity we just check existence)
 	for _, k := range []string{"a", "b", "c", "d"} {
@@ -52,8 +71,277 @@ func demo(policy eviction.Evictor) {
 	}
 }
 
+func demoTTL(x expiry.Expirer) {
+	fmt.Printf("\n===== TTL (expirer: %s) =====\n", x.Name())
+
+	s := store.NewStore(0, 0, eviction.NewLRUEvictor(), store.WithExpirer(x))
+	s.OnEvict(printEvicted)
+	s.StartActiveExpiry(10 * time.Millisecond)
+	defer s.Close()
+
+	mustSet(s, "forever", "1")
+	if err := s.SetWithTTL("session", []byte("abc"), 50*time.Millisecond); err != nil {
+		panic(err)
+	}
+
+	ttl, _ := s.TTL("session")
+	fmt.Printf("session ttl=%s\n", ttl.Round(time.Millisecond))
+
+	time.Sleep(100 * time.Millisecond)
+
+	// The background expirer should have reclaimed "session" without a Get.
+	st := s.Stats()
+	fmt.Printf("Stats after expiry: keys=%d bytes=%d expirations=%d\n", st.Keys, st.Bytes, st.Expirations)
+	if _, ok := s.Get("session"); !ok {
+		fmt.Println("  session=(expired)")
+	}
+}
+
+func demoVolatile(policy eviction.Evictor) {
+	fmt.Printf("\n===== Policy: %s =====\n", policy.Name())
+
+	s := store.NewStore(3, 0, policy)
+	defer s.Close()
+
+	mustSet(s, "config", "persistent")
+	for i, ttl := range []time.Duration{time.Minute, time.Second} {
+		k := fmt.Sprintf("cache:%d", i)
+		if err := s.SetWithTTL(k, []byte("v"), ttl); err != nil {
+			panic(err)
+		}
+	}
+
+	// Full: only keys with a TTL are candidates (noeviction rejects the write).
+	if err := s.Set("new", []byte("v")); err != nil {
+		fmt.Printf("Set new: %v\n", err)
+	}
+	for _, k := range []string{"config", "cache:0", "cache:1", "new"} {
+		if _, ok := s.Get(k); ok {
+			fmt.Printf("  %s=(present)\n", k)
+		} else {
+			fmt.Printf("  %s=(missing)\n", k)
+		}
+	}
+}
+
+const hitRatioCapacity = 500
+
+// hitRatio replays the same skewed (zipf) read-through workload against a
+// small store, so policies can be compared on equal terms. With scans, every
+// 4th request reads the next key of a long sequential scan, which is never
+// requested again.
+func hitRatio(policy eviction.Evictor, scans bool) (float64, *store.Store) {
+	const (
+		keyspace = 10_000
+		ops      = 200_000
+	)
+	s := store.NewStore(hitRatioCapacity, 0, policy)
+	zipf := rand.NewZipf(rand.New(rand.NewSource(42)), 1.1, 1, keyspace-1)
+
+	for i := 0; i < ops; i++ {
+		k := fmt.Sprintf("k%d", zipf.Uint64())
+		if scans && i%4 == 0 {
+			k = fmt.Sprintf("scan%d", i)
+		}
+		if _, ok := s.Get(k); ok {
+			continue
+		}
+		if err := s.Set(k, []byte("v")); err != nil && !errors.Is(err, store.ErrNotAdmitted) {
+			panic(err)
+		}
+	}
+	return s.Stats().HitRatio(), s
+}
+
+func demoHitRatio() {
+	fmt.Printf("\n===== Hit ratio (zipf, zipf+scan) =====\n")
+	for _, newPolicy := range []func() eviction.Evictor{
+		func() eviction.Evictor { return eviction.NewLRUEvictor() },
+		func() eviction.Evictor { return eviction.NewApproxLRUEvictor(5, eviction.NewCounterClock()) },
+		func() eviction.Evictor { return eviction.NewLFUEvictor() },
+		func() eviction.Evictor { return eviction.NewApproxLFUEvictor(5, 10, time.Minute) },
+		func() eviction.Evictor { return eviction.NewRandomEvictor() },
+		func() eviction.Evictor { return eviction.NewSLRUEvictor() },
+		func() eviction.Evictor { return eviction.NewARCEvictor(hitRatioCapacity) },
+		func() eviction.Evictor { return eviction.NewWTinyLFUEvictor(hitRatioCapacity) },
+		func() eviction.Evictor {
+			return eviction.NewTinyLFUAdmission(eviction.NewLRUEvictor(), hitRatioCapacity)
+		},
+	} {
+		ratio, s := hitRatio(newPolicy(), false)
+		scanRatio, _ := hitRatio(newPolicy(), true)
+		st := s.Stats()
+		fmt.Printf("  %-14s %.4f  %.4f  evictions=%d rejected=%d", st.Policy, ratio, scanRatio, st.Evictions(), st.RejectedSets)
+		// The hottest key of the workload, as seen by frequency-tracking policies.
+		if freq, ok, err := s.Frequency("k1"); err == nil && ok {
+			fmt.Printf("  freq(k1)=%d", freq)
+		}
+		fmt.Println()
+	}
+}
+
+// demoSizing compares the logical and the estimated runtime size of a store
+// with what the Go heap actually grew by.
+func demoSizing() {
+	fmt.Printf("\n===== Memory accounting =====\n")
+	const n = 100_000
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	s := store.NewStore(0, 0, eviction.NewLRUEvictor(), store.WithSizeEstimator(store.RuntimeSize{}))
+	for i := 0; i < n; i++ {
+		mustSet(s, fmt.Sprintf("user:%d", i), "0123456789")
+	}
+
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	st := s.Stats()
+	fmt.Printf("  logical=%d estimated(%s)=%d heap delta=%d\n",
+		st.LogicalBytes, st.Sizing, st.Bytes, int64(after.HeapAlloc)-int64(before.HeapAlloc))
+	runtime.KeepAlive(s)
+}
+
+func demoTypes() {
+	fmt.Printf("\n===== Typed values =====\n")
+
+	s := store.NewStore(0, 100, eviction.NewLRUEvictor())
+	s.OnEvict(printEvicted)
+
+	_, _ = s.RPush("queue", []byte("a"), []byte("b"))
+	_, _ = s.LPush("queue", []byte("z"))
+	items, _ := s.LRange("queue", 0, -1)
+	fmt.Printf("  LRANGE queue 0 -1 -> %q\n", items)
+
+	_, _ = s.SAdd("tags", "go", "redis", "go")
+	isMember, _ := s.SIsMember("tags", "redis")
+	fmt.Printf("  SISMEMBER tags redis -> %v\n", isMember)
+
+	_, _ = s.HSet("user:1", "name", []byte("thanh"))
+	name, _, _ := s.HGet("user:1", "name")
+	fmt.Printf("  HGET user:1 name -> %s\n", name)
+
+	_, _ = s.ZAdd("board", 30, "carol")
+	_, _ = s.ZAdd("board", 10, "alice")
+	_, _ = s.ZAdd("board", 20, "bob")
+	top, _ := s.ZRange("board", 0, 1)
+	rank, _, _ := s.ZRank("board", "carol")
+	fmt.Printf("  ZRANGE board 0 1 -> %v, ZRANK board carol -> %d\n", top, rank)
+
+	if _, err := s.SAdd("queue", "x"); err != nil {
+		fmt.Printf("  SADD queue x -> %v\n", err)
+	}
+	if _, _, err := s.GetString("queue"); err != nil {
+		fmt.Printf("  GET queue -> %v\n", err)
+	}
+
+	st := s.Stats()
+	fmt.Printf("  keys=%d bytes=%d (limit 100)\n", st.Keys, st.Bytes)
+
+	// Growing the hash past maxBytes evicts the least recently used keys.
+	_, _ = s.HSet("user:1", "bio", []byte("a fairly long biography string"))
+	st = s.Stats()
+	fmt.Printf("  keys=%d bytes=%d after HSET user:1 bio\n", st.Keys, st.Bytes)
+}
+
+// kv is what demoConcurrency needs from Store and Sharded.
+type kv interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, val []byte) error
+	Stats() store.Stats
+}
+
+// demoConcurrency runs the same parallel read-through workload against one
+// Store and a Sharded store with the same total capacity.
+func demoConcurrency() {
+	fmt.Printf("\n===== Concurrency: single lock vs. sharded =====\n")
+	const (
+		workers   = 8
+		perWorker = 200_000
+		capacity  = 10_000
+		keyspace  = 100_000
+	)
+	run := func(name string, s kv) {
+		var wg sync.WaitGroup
+		start := time.Now()
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func(seed int64) {
+				defer wg.Done()
+				zipf := rand.NewZipf(rand.New(rand.NewSource(seed)), 1.1, 1, keyspace-1)
+				for i := 0; i < perWorker; i++ {
+					k := fmt.Sprintf("k%d", zipf.Uint64())
+					if _, ok := s.Get(k); !ok {
+						_ = s.Set(k, []byte("v"))
+					}
+				}
+			}(int64(w))
+		}
+		wg.Wait()
+		elapsed := time.Since(start)
+		st := s.Stats()
+		fmt.Printf("  %-8s %8.0f ops/s  hit ratio=%.4f keys=%d\n",
+			name, float64(workers*perWorker)/elapsed.Seconds(), st.HitRatio(), st.Keys)
+	}
+
+	run("single", store.NewStore(capacity, 0, eviction.NewLRUEvictor()))
+	run("sharded", store.NewSharded(32, capacity, 0, func(maxKeys int, maxBytes int64) *store.Store {
+		return store.NewStore(maxKeys, maxBytes, eviction.NewLRUEvictor())
+	}))
+}
+
+// demoConditional runs the compare-and-swap deposit loop from
+// concurrency-optimistic against a store key, then the SET NX/XX/GET variants.
+func demoConditional() {
+	fmt.Printf("\n===== Conditional writes =====\n")
+
+	s := store.NewStore(0, 0, eviction.NewLRUEvictor())
+	_ = s.Set("balance", []byte("0"))
+
+	const workers = 100
+	var wg sync.WaitGroup
+	var retries atomic.Int64
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				val, ver, _ := s.GetVersion("balance")
+				n, _ := strconv.Atoi(string(val))
+				if _, err := s.CompareAndSet("balance", ver, []byte(strconv.Itoa(n+1))); err == nil {
+					return
+				}
+				retries.Add(1) // lost the race; retry
+			}
+		}()
+	}
+	wg.Wait()
+	val, ver, _ := s.GetVersion("balance")
+	fmt.Printf("  balance=%s version=%d after %d deposits (%d retries)\n", val, ver, workers, retries.Load())
+
+	ok, _ := s.SetNX("lock", []byte("owner-1"))
+	fmt.Printf("  SET lock owner-1 NX -> %v\n", ok)
+	ok, _ = s.SetNX("lock", []byte("owner-2"))
+	fmt.Printf("  SET lock owner-2 NX -> %v\n", ok)
+	ok, _ = s.SetXX("missing", []byte("v"))
+	fmt.Printf("  SET missing v XX -> %v\n", ok)
+	old, _, _ := s.SetGet("lock", []byte("owner-3"))
+	fmt.Printf("  SET lock owner-3 GET -> %s\n", old)
+}
+
 func main() {
 	demo(eviction.NewLRUEvictor())
 	demo(eviction.NewLFUEvictor())
 	demo(eviction.NewRandomEvictor())
+	demoVolatile(eviction.NewVolatileLRUEvictor())
+	demoVolatile(eviction.NewVolatileTTLEvictor())
+	demoVolatile(eviction.NewNoEvictionEvictor())
+	demoTTL(expiry.NewSamplingExpirer())
+	demoTTL(expiry.NewHeapExpirer())
+	demoHitRatio()
+	demoSizing()
+	demoConcurrency()
+	demoTypes()
+	demoConditional()
 }
@@ -0,0 +1,115 @@
+package store
+
+import (
+	"errors"
+	"time"
+
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/eviction-policies/lib"
+)
+
+// ErrVersionMismatch is returned by CompareAndSet when the key was written
+// (or created, or deleted) since the caller read its version.
+var ErrVersionMismatch = errors.New("version mismatch: key was modified concurrently")
+
+// errSkip aborts a conditional write without reporting an error.
+var errSkip = errors.New("condition not met")
+
+// GetVersion is Get that also returns the entry's version, for use with
+// CompareAndSet.
+func (s *Store) GetVersion(key string) (val []byte, version uint64, ok bool) {
+	_, err := s.read(key, lib.TypeString, func(e *lib.Entry) {
+		val = append([]byte(nil), e.Value...)
+		version, ok = e.Version, true
+	})
+	if err != nil {
+		return nil, 0, false
+	}
+	return val, version, ok
+}
+
+// SetNX stores val only if key doesn't exist and reports whether it did.
+func (s *Store) SetNX(key string, val []byte) (bool, error) {
+	_, err := s.setIf(key, val, func(e *lib.Entry) error {
+		if e != nil {
+			return errSkip
+		}
+		return nil
+	})
+	return skipped(err)
+}
+
+// SetXX stores val only if key already exists and reports whether it did.
+func (s *Store) SetXX(key string, val []byte) (bool, error) {
+	_, err := s.setIf(key, val, func(e *lib.Entry) error {
+		if e == nil {
+			return errSkip
+		}
+		return nil
+	})
+	return skipped(err)
+}
+
+// SetGet stores val and returns the value it replaced (SET key val GET).
+// A key holding a collection is ErrWrongType and is left untouched.
+func (s *Store) SetGet(key string, val []byte) (old []byte, existed bool, err error) {
+	_, err = s.setIf(key, val, func(e *lib.Entry) error {
+		if e == nil {
+			return nil
+		}
+		if e.Type != lib.TypeString {
+			return ErrWrongType
+		}
+		old, existed = append([]byte(nil), e.Value...), true
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return old, existed, nil
+}
+
+// CompareAndSet stores val only if key is still at version expected (0
+// means the key must not exist) and returns the new version. Like Set it
+// clears any TTL.
+func (s *Store) CompareAndSet(key string, expected uint64, val []byte) (uint64, error) {
+	return s.setIf(key, val, func(e *lib.Entry) error {
+		var current uint64
+		if e != nil {
+			current = e.Version
+		}
+		if current != expected {
+			return ErrVersionMismatch
+		}
+		return nil
+	})
+}
+
+// setIf is Set guarded by check, which sees the live entry (nil for a
+// missing key) in the same critical section as the write. An error from
+// check leaves the store unchanged and is returned as is.
+func (s *Store) setIf(key string, val []byte, check func(e *lib.Entry) error) (uint64, error) {
+	if key == "" {
+		return 0, errors.New("key must not be empty")
+	}
+
+	s.mu.Lock()
+	defer s.unlock()
+	s.drainReadsLocked()
+
+	e, _ := s.lookupLocked(key, time.Now().UnixNano())
+	if err := check(e); err != nil {
+		return 0, err
+	}
+	if err := s.setLocked(key, val, 0); err != nil {
+		return 0, err
+	}
+	return s.items[key].Version, nil
+}
+
+// skipped turns errSkip into a plain "not written".
+func skipped(err error) (bool, error) {
+	if errors.Is(err, errSkip) {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,41 @@
+package store
+
+import "github.com/vnscriptkid/sd-keyvalue-store/bytes/eviction-policies/lib"
+
+// EvictListener is called for every entry the store removes on its own.
+// It runs after the store lock is released, so it may call back into the store.
+type EvictListener func(ev EvictEvent)
+
+// EvictEvent is an entry the store removed. Value holds a string's bytes;
+// Object holds the *lib.List, *lib.Set, *lib.Hash or *lib.ZSet of the
+// other types, so a listener can write any evicted key to a lower tier.
+// The store no longer references either: the listener may keep them.
+type EvictEvent struct {
+	Key    string
+	Type   lib.Type
+	Value  []byte
+	Object any
+	Reason Reason
+}
+
+// OnEvict registers fn for evictions (ReasonMaxKeys, ReasonMaxBytes) and
+// expirations (ReasonExpired). Explicit deletes and overwrites are not reported.
+func (s *Store) OnEvict(fn EvictListener) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, fn)
+}
+
+// unlock releases the store lock and then delivers events queued while it
+// was held. Use it instead of s.mu.Unlock() wherever entries can be removed.
+func (s *Store) unlock() {
+	events, listeners := s.pending, s.listeners
+	s.pending = nil
+	s.mu.Unlock()
+
+	for _, ev := range events {
+		for _, fn := range listeners {
+			fn(ev)
+		}
+	}
+}
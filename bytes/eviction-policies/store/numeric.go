@@ -0,0 +1,102 @@
+package store
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/eviction-policies/lib"
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/rediscompat"
+)
+
+// The numeric errors are shared with bytes/kv.
+var (
+	ErrNotInteger = rediscompat.ErrNotInteger
+	ErrNotFloat   = rediscompat.ErrNotFloat
+	ErrOverflow   = rediscompat.ErrOverflow
+	ErrNaNOrInf   = rediscompat.ErrNaNOrInf
+)
+
+// Incr adds 1 to the integer stored at key.
+func (s *Store) Incr(key string) (int64, error) { return s.IncrBy(key, 1) }
+
+// Decr subtracts 1 from the integer stored at key.
+func (s *Store) Decr(key string) (int64, error) { return s.IncrBy(key, -1) }
+
+// DecrBy subtracts delta from the integer stored at key.
+func (s *Store) DecrBy(key string, delta int64) (int64, error) {
+	if delta == math.MinInt64 {
+		return 0, ErrOverflow
+	}
+	return s.IncrBy(key, -delta)
+}
+
+// IncrBy atomically adds delta to the integer stored at key and returns the
+// new value. A missing key counts as 0; an existing TTL is kept.
+func (s *Store) IncrBy(key string, delta int64) (int64, error) {
+	var n int64
+	err := s.update(key, func(old []byte, found bool) ([]byte, error) {
+		if found {
+			v, err := strconv.ParseInt(string(old), 10, 64)
+			if err != nil {
+				return nil, ErrNotInteger
+			}
+			n = v
+		}
+		if (delta > 0 && n > math.MaxInt64-delta) || (delta < 0 && n < math.MinInt64-delta) {
+			return nil, ErrOverflow
+		}
+		n += delta
+		return strconv.AppendInt(nil, n, 10), nil
+	})
+	return n, err
+}
+
+// IncrByFloat atomically adds delta to the number stored at key and returns
+// the new value. A missing key counts as 0; an existing TTL is kept.
+func (s *Store) IncrByFloat(key string, delta float64) (float64, error) {
+	var f float64
+	err := s.update(key, func(old []byte, found bool) ([]byte, error) {
+		if found {
+			v, err := strconv.ParseFloat(string(old), 64)
+			if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+				return nil, ErrNotFloat
+			}
+			f = v
+		}
+		f += delta
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, ErrNaNOrInf
+		}
+		return strconv.AppendFloat(nil, f, 'f', -1, 64), nil
+	})
+	return f, err
+}
+
+// update replaces the string at key with fn(old) in one critical section.
+// found is false for a missing key; fn's error leaves the store unchanged.
+func (s *Store) update(key string, fn func(old []byte, found bool) ([]byte, error)) error {
+	if key == "" {
+		return errors.New("key must not be empty")
+	}
+
+	s.mu.Lock()
+	defer s.unlock()
+	s.drainReadsLocked()
+
+	var old []byte
+	var expireAt int64
+	e, found := s.lookupLocked(key, time.Now().UnixNano())
+	if found {
+		if e.Type != lib.TypeString {
+			return ErrWrongType
+		}
+		old, expireAt = e.Value, e.ExpireAt
+	}
+	val, err := fn(old, found)
+	if err != nil {
+		return err
+	}
+	return s.setLocked(key, val, expireAt)
+}
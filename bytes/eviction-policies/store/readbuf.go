@@ -0,0 +1,75 @@
+package store
+
+import (
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/eviction-policies/lib"
+)
+
+// Read buffer sizing (Caffeine-style): several small stripes so concurrent
+// readers rarely meet on the same one.
+const (
+	readBufferStripes = 16 // power of two
+	readStripeSize    = 16
+)
+
+type readStripe struct {
+	mu  sync.Mutex
+	buf [readStripeSize]*lib.Entry
+	n   int
+}
+
+// readBuffer collects Get hits so the evictor can be told about them later,
+// in a batch, under the store's write lock. It is lossy on purpose: when a
+// stripe is busy or full the access is dropped, which costs the policy a
+// little precision but never blocks a reader.
+type readBuffer struct {
+	stripes [readBufferStripes]readStripe
+	dropped atomic.Uint64
+}
+
+// record buffers an access to e and reports whether its stripe is now full,
+// i.e. whether the caller should try to drain.
+func (b *readBuffer) record(e *lib.Entry) (full bool) {
+	st := &b.stripes[rand.Uint32()&(readBufferStripes-1)]
+	if !st.mu.TryLock() {
+		b.dropped.Add(1)
+		return false
+	}
+	defer st.mu.Unlock()
+
+	if st.n == readStripeSize {
+		b.dropped.Add(1)
+		return true
+	}
+	st.buf[st.n] = e
+	st.n++
+	return st.n == readStripeSize
+}
+
+// drain hands every buffered access to fn and empties the buffer.
+func (b *readBuffer) drain(fn func(e *lib.Entry)) {
+	for i := range b.stripes {
+		st := &b.stripes[i]
+		st.mu.Lock()
+		for j := 0; j < st.n; j++ {
+			fn(st.buf[j])
+			st.buf[j] = nil
+		}
+		st.n = 0
+		st.mu.Unlock()
+	}
+}
+
+// drainReadsLocked replays buffered Get hits into the evictor. Call it
+// before anything that asks the evictor for a decision.
+func (s *Store) drainReadsLocked() {
+	s.reads.drain(func(e *lib.Entry) {
+		// skip entries removed or replaced since the read
+		if cur, ok := s.items[e.Key]; ok && cur == e {
+			s.evictor.OnGet(e)
+		}
+	})
+}
@@ -0,0 +1,209 @@
+package store
+
+import (
+	"hash/fnv"
+	"time"
+
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/eviction-policies/lib"
+)
+
+// Sharded spreads the keyspace over independent Stores, each with its own
+// lock, evictor and a proportional slice of the limits (same idea as
+// Sharded in bytes/shard-map). Operations on different shards don't contend.
+// Eviction is per shard, so it is only approximately global: with a decent
+// hash every shard sees a similar slice of the workload, and the coldest key
+// of a shard is close to the coldest key overall.
+type Sharded struct {
+	shards []*Store
+}
+
+// NewSharded creates n shards with newShard, passing each its share of
+// maxKeys and maxBytes (0 still means "no limit"). A non-zero limit smaller
+// than n is rounded up to 1 per shard; n < 1 means a single shard.
+func NewSharded(n int, maxKeys int, maxBytes int64, newShard func(maxKeys int, maxBytes int64) *Store) *Sharded {
+	if n < 1 {
+		n = 1
+	}
+	s := &Sharded{shards: make([]*Store, n)}
+	for i := range s.shards {
+		s.shards[i] = newShard(int(shareOf(int64(maxKeys), n, i)), shareOf(maxBytes, n, i))
+	}
+	return s
+}
+
+// shareOf splits limit into n parts that differ by at most one.
+func shareOf(limit int64, n, i int) int64 {
+	if limit <= 0 {
+		return 0
+	}
+	share := limit / int64(n)
+	if int64(i) < limit%int64(n) {
+		share++
+	}
+	if share == 0 {
+		share = 1
+	}
+	return share
+}
+
+// fnv is OK for demo; replace with xxhash for speed
+func (s *Sharded) shardOf(key string) *Store {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return s.shards[h.Sum32()%uint32(len(s.shards))]
+}
+
+func (s *Sharded) Get(key string) ([]byte, bool) { return s.shardOf(key).Get(key) }
+
+func (s *Sharded) GetString(key string) ([]byte, bool, error) { return s.shardOf(key).GetString(key) }
+
+func (s *Sharded) Set(key string, val []byte) error { return s.shardOf(key).Set(key, val) }
+
+func (s *Sharded) SetWithTTL(key string, val []byte, ttl time.Duration) error {
+	return s.shardOf(key).SetWithTTL(key, val, ttl)
+}
+
+func (s *Sharded) GetVersion(key string) ([]byte, uint64, bool) {
+	return s.shardOf(key).GetVersion(key)
+}
+
+func (s *Sharded) SetNX(key string, val []byte) (bool, error) { return s.shardOf(key).SetNX(key, val) }
+
+func (s *Sharded) SetXX(key string, val []byte) (bool, error) { return s.shardOf(key).SetXX(key, val) }
+
+func (s *Sharded) SetGet(key string, val []byte) ([]byte, bool, error) {
+	return s.shardOf(key).SetGet(key, val)
+}
+
+func (s *Sharded) CompareAndSet(key string, expected uint64, val []byte) (uint64, error) {
+	return s.shardOf(key).CompareAndSet(key, expected, val)
+}
+
+func (s *Sharded) Del(key string) bool { return s.shardOf(key).Del(key) }
+
+func (s *Sharded) Expire(key string, ttl time.Duration) bool {
+	return s.shardOf(key).Expire(key, ttl)
+}
+
+func (s *Sharded) TTL(key string) (time.Duration, bool) { return s.shardOf(key).TTL(key) }
+
+func (s *Sharded) Persist(key string) bool { return s.shardOf(key).Persist(key) }
+
+func (s *Sharded) Frequency(key string) (int, bool, error) { return s.shardOf(key).Frequency(key) }
+
+func (s *Sharded) Incr(key string) (int64, error) { return s.shardOf(key).Incr(key) }
+
+func (s *Sharded) Decr(key string) (int64, error) { return s.shardOf(key).Decr(key) }
+
+func (s *Sharded) DecrBy(key string, delta int64) (int64, error) {
+	return s.shardOf(key).DecrBy(key, delta)
+}
+
+func (s *Sharded) IncrBy(key string, delta int64) (int64, error) {
+	return s.shardOf(key).IncrBy(key, delta)
+}
+
+func (s *Sharded) IncrByFloat(key string, delta float64) (float64, error) {
+	return s.shardOf(key).IncrByFloat(key, delta)
+}
+
+func (s *Sharded) Type(key string) (lib.Type, bool) { return s.shardOf(key).Type(key) }
+
+func (s *Sharded) LPush(key string, vals ...[]byte) (int, error) {
+	return s.shardOf(key).LPush(key, vals...)
+}
+
+func (s *Sharded) RPush(key string, vals ...[]byte) (int, error) {
+	return s.shardOf(key).RPush(key, vals...)
+}
+
+func (s *Sharded) LPop(key string) ([]byte, bool, error) { return s.shardOf(key).LPop(key) }
+
+func (s *Sharded) LRange(key string, start, stop int) ([][]byte, error) {
+	return s.shardOf(key).LRange(key, start, stop)
+}
+
+func (s *Sharded) SAdd(key string, members ...string) (int, error) {
+	return s.shardOf(key).SAdd(key, members...)
+}
+
+func (s *Sharded) SRem(key string, members ...string) (int, error) {
+	return s.shardOf(key).SRem(key, members...)
+}
+
+func (s *Sharded) SMembers(key string) ([]string, error) { return s.shardOf(key).SMembers(key) }
+
+func (s *Sharded) SIsMember(key, member string) (bool, error) {
+	return s.shardOf(key).SIsMember(key, member)
+}
+
+func (s *Sharded) HSet(key, field string, val []byte) (bool, error) {
+	return s.shardOf(key).HSet(key, field, val)
+}
+
+func (s *Sharded) HGet(key, field string) ([]byte, bool, error) {
+	return s.shardOf(key).HGet(key, field)
+}
+
+func (s *Sharded) HDel(key string, fields ...string) (int, error) {
+	return s.shardOf(key).HDel(key, fields...)
+}
+
+func (s *Sharded) HGetAll(key string) (map[string][]byte, error) {
+	return s.shardOf(key).HGetAll(key)
+}
+
+func (s *Sharded) ZAdd(key string, score float64, member string) (bool, error) {
+	return s.shardOf(key).ZAdd(key, score, member)
+}
+
+func (s *Sharded) ZRange(key string, start, stop int) ([]lib.ZMember, error) {
+	return s.shardOf(key).ZRange(key, start, stop)
+}
+
+func (s *Sharded) ZRank(key, member string) (int, bool, error) {
+	return s.shardOf(key).ZRank(key, member)
+}
+
+// Keys locks one shard at a time, so the result is not a point-in-time snapshot.
+func (s *Sharded) Keys() []string {
+	var out []string
+	for _, sh := range s.shards {
+		out = append(out, sh.Keys()...)
+	}
+	return out
+}
+
+// Stats sums usage and counters over all shards.
+func (s *Sharded) Stats() Stats {
+	var total Stats
+	for _, sh := range s.shards {
+		total.add(sh.Stats())
+	}
+	return total
+}
+
+func (s *Sharded) ResetStats() {
+	for _, sh := range s.shards {
+		sh.ResetStats()
+	}
+}
+
+// OnEvict registers fn on every shard.
+func (s *Sharded) OnEvict(fn EvictListener) {
+	for _, sh := range s.shards {
+		sh.OnEvict(fn)
+	}
+}
+
+func (s *Sharded) StartActiveExpiry(interval time.Duration) {
+	for _, sh := range s.shards {
+		sh.StartActiveExpiry(interval)
+	}
+}
+
+func (s *Sharded) Close() {
+	for _, sh := range s.shards {
+		sh.Close()
+	}
+}
@@ -0,0 +1,71 @@
+package store
+
+import (
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/eviction-policies/eviction"
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/eviction-policies/lib"
+)
+
+// SizeEstimator decides how many bytes an entry is charged against maxBytes.
+// overhead is the per-entry bookkeeping of the evictor and expirer tracking
+// it, as reported by their NodeBytes methods.
+type SizeEstimator interface {
+	Name() string
+	EntryBytes(e *lib.Entry, overhead int64) int64
+}
+
+// LogicalSize charges key + value bytes only. Simple and predictable, but the
+// process can use several times maxBytes. This is the default.
+type LogicalSize struct{}
+
+func (LogicalSize) Name() string { return "LOGICAL" }
+
+func (LogicalSize) EntryBytes(e *lib.Entry, overhead int64) int64 {
+	return e.LogicalBytes()
+}
+
+// RuntimeSize estimates what an entry really costs in the Go heap: the key
+// and value allocations (every element of a collection), the lib.Entry
+// struct, the store's map slot and the evictor/expirer nodes.
+type RuntimeSize struct{}
+
+func (RuntimeSize) Name() string { return "RUNTIME" }
+
+func (RuntimeSize) EntryBytes(e *lib.Entry, overhead int64) int64 {
+	return lib.AllocBytes(int64(len(e.Key))) +
+		valueRuntimeBytes(e) +
+		lib.EntryStructBytes +
+		lib.MapSlotBytes(lib.StringHeaderBytes, lib.PointerBytes) + // items
+		overhead
+}
+
+// valueRuntimeBytes is O(1): collections keep their estimate as they change.
+func valueRuntimeBytes(e *lib.Entry) int64 {
+	if c, ok := e.Object.(lib.Collection); ok {
+		return c.HeapBytes()
+	}
+	return lib.AllocBytes(int64(cap(e.Value)))
+}
+
+// WithSizeEstimator selects how entries are charged against maxBytes.
+func WithSizeEstimator(est SizeEstimator) Option {
+	return func(s *Store) { s.sizer = est }
+}
+
+// entryBytes is what e costs under the store's estimator.
+func (s *Store) entryBytes(e *lib.Entry) int64 {
+	var overhead int64
+	if ns, ok := s.evictor.(eviction.NodeSizer); ok {
+		overhead += ns.NodeBytes()
+	}
+	if ns, ok := s.expirer.(eviction.NodeSizer); ok && e.ExpireAt > 0 {
+		overhead += ns.NodeBytes()
+	}
+	return s.sizer.EntryBytes(e, overhead)
+}
+
+// resizeLocked re-charges e after a change that affects its estimated size.
+func (s *Store) resizeLocked(e *lib.Entry) {
+	n := s.entryBytes(e)
+	s.bytesUsed += n - e.Bytes
+	e.Bytes = n
+}
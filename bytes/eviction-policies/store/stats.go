@@ -0,0 +1,122 @@
+package store
+
+import "github.com/vnscriptkid/sd-keyvalue-store/bytes/eviction-policies/lib"
+
+// Reason says why the store removed an entry on its own.
+type Reason int
+
+const (
+	ReasonMaxKeys  Reason = iota + 1 // evicted to stay under maxKeys
+	ReasonMaxBytes                   // evicted to stay under maxBytes
+	ReasonExpired                    // TTL passed (lazy or active expiry)
+)
+
+func (r Reason) String() string {
+	switch r {
+	case ReasonMaxKeys:
+		return "maxKeys"
+	case ReasonMaxBytes:
+		return "maxBytes"
+	case ReasonExpired:
+		return "expired"
+	default:
+		return "unknown"
+	}
+}
+
+// Stats is a snapshot of store usage and counters since creation or the
+// last ResetStats.
+type Stats struct {
+	Keys         int
+	Bytes        int64 // charged by the size estimator, limited by maxBytes
+	LogicalBytes int64 // key + value bytes
+	Policy       string
+	Sizing       string
+
+	Hits   uint64 // Get found a live key
+	Misses uint64 // Get found nothing (or an expired key)
+
+	EvictionsMaxKeys  uint64
+	EvictionsMaxBytes uint64
+	BytesEvicted      int64
+
+	Expirations  uint64 // lazy and active
+	RejectedSets uint64 // ErrOutOfMemory or ErrNotAdmitted
+	DroppedReads uint64 // Get hits the evictor never saw (read buffer full or busy)
+}
+
+func (st Stats) Evictions() uint64 { return st.EvictionsMaxKeys + st.EvictionsMaxBytes }
+
+func (st Stats) HitRatio() float64 {
+	if st.Hits+st.Misses == 0 {
+		return 0
+	}
+	return float64(st.Hits) / float64(st.Hits+st.Misses)
+}
+
+// add accumulates o into st; Policy and Sizing are taken from the first shard.
+func (st *Stats) add(o Stats) {
+	if st.Policy == "" {
+		st.Policy, st.Sizing = o.Policy, o.Sizing
+	}
+	st.Keys += o.Keys
+	st.Bytes += o.Bytes
+	st.LogicalBytes += o.LogicalBytes
+	st.Hits += o.Hits
+	st.Misses += o.Misses
+	st.EvictionsMaxKeys += o.EvictionsMaxKeys
+	st.EvictionsMaxBytes += o.EvictionsMaxBytes
+	st.BytesEvicted += o.BytesEvicted
+	st.Expirations += o.Expirations
+	st.RejectedSets += o.RejectedSets
+	st.DroppedReads += o.DroppedReads
+}
+
+func (s *Store) Stats() Stats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	st := s.stats
+	st.Hits = s.hits.Load()
+	st.Misses = s.misses.Load()
+	st.DroppedReads = s.reads.dropped.Load()
+	st.Keys = s.keysUsed
+	st.Bytes = s.bytesUsed
+	st.LogicalBytes = s.logicalBytes
+	st.Policy = s.evictor.Name()
+	st.Sizing = s.sizer.Name()
+	return st
+}
+
+// ResetStats zeroes the counters; usage (Keys, Bytes, LogicalBytes) is not affected.
+func (s *Store) ResetStats() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats = Stats{}
+	s.hits.Store(0)
+	s.misses.Store(0)
+	s.reads.dropped.Store(0)
+}
+
+func (s *Store) countEvictionLocked(e *lib.Entry, r Reason) {
+	switch r {
+	case ReasonMaxKeys:
+		s.stats.EvictionsMaxKeys++
+	case ReasonMaxBytes:
+		s.stats.EvictionsMaxBytes++
+	}
+	s.stats.BytesEvicted += e.Bytes
+	s.queueEvictLocked(e, r)
+}
+
+func (s *Store) countExpirationLocked(e *lib.Entry) {
+	s.stats.Expirations++
+	s.queueEvictLocked(e, ReasonExpired)
+}
+
+func (s *Store) queueEvictLocked(e *lib.Entry, r Reason) {
+	if len(s.listeners) == 0 {
+		return
+	}
+	s.pending = append(s.pending, EvictEvent{Key: e.Key, Type: e.Type, Value: e.Value, Object: e.Object, Reason: r})
+}
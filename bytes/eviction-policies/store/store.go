@@ -4,13 +4,37 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/vnscriptkid/sd-keyvalue-store/bytes/eviction-policies/eviction"
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/eviction-policies/expiry"
 	"github.com/vnscriptkid/sd-keyvalue-store/bytes/eviction-policies/lib"
 )
 
+// Upper bound of keys reclaimed by one active expiry cycle, so a burst of
+// expirations can't hold the store lock for too long.
+const maxExpiredPerCycle = 256
+
+// ErrOutOfMemory is returned by writes that would exceed the limits when the
+// evictor has no victim to offer (noeviction, or a volatile policy with no
+// volatile keys).
+var ErrOutOfMemory = errors.New("out of memory: write rejected, no key can be evicted")
+
+// ErrNotAdmitted is returned when an eviction.Admitter keeps its victim
+// rather than admitting the new key.
+var ErrNotAdmitted = errors.New("write rejected by admission policy")
+
+// ErrNoFrequency is returned by Frequency when the evictor doesn't count accesses.
+var ErrNoFrequency = errors.New("frequency not tracked: evictor is not an LFU policy")
+
+// NoExpiry is returned by TTL for keys that exist but have no deadline.
+const NoExpiry time.Duration = -1
+
 type Store struct {
-	mu sync.Mutex
+	// Get holds mu for reading and leaves evictor bookkeeping to reads;
+	// everything that changes items or the evictor holds it exclusively.
+	mu sync.RWMutex
 
 	items map[string]*lib.Entry
 
@@ -18,95 +42,197 @@ type Store struct {
 	maxKeys  int
 	maxBytes int64
 
-	// Current usage
-	keysUsed  int
-	bytesUsed int64
+	// Current usage. bytesUsed is what sizer charges and what maxBytes
+	// limits; logicalBytes is plain key + value bytes.
+	keysUsed     int
+	bytesUsed    int64
+	logicalBytes int64
+
+	version uint64 // last Entry.Version handed out
+
+	sizer SizeEstimator
 
 	evictor eviction.Evictor
+	expirer expiry.Expirer // indexes entries that carry a deadline
+
+	reads readBuffer // Get hits not yet seen by the evictor
+
+	stats        Stats         // counters only; usage fields are filled in by Stats()
+	hits, misses atomic.Uint64 // updated under the read lock
+
+	listeners []EvictListener
+	pending   []EvictEvent // delivered by unlock()
+
+	stopExpiry chan struct{}
+	expiryDone chan struct{}
 }
 
-func NewStore(maxKeys int, maxBytes int64, evictor eviction.Evictor) *Store {
-	return &Store{
+// Option customizes a Store created by NewStore.
+type Option func(*Store)
+
+// WithExpirer selects how keys with a TTL are indexed for active expiry.
+// The default is expiry.NewSamplingExpirer().
+func WithExpirer(x expiry.Expirer) Option {
+	return func(s *Store) { s.expirer = x }
+}
+
+func NewStore(maxKeys int, maxBytes int64, evictor eviction.Evictor, opts ...Option) *Store {
+	s := &Store{
 		items:    make(map[string]*lib.Entry),
 		maxKeys:  maxKeys,
 		maxBytes: maxBytes,
 		evictor:  evictor,
+		expirer:  expiry.NewSamplingExpirer(),
+		sizer:    LogicalSize{},
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
-// Simplified memory accounting: key bytes + value bytes.
-// Real overhead is higher; this is a learning/demo approximation.
-func approxEntryBytes(key string, val []byte) int64 {
-	return int64(len(key) + len(val))
+// Get returns the string stored at key. A key holding a collection reads
+// as missing; GetString tells the two apart.
+func (s *Store) Get(key string) ([]byte, bool) {
+	v, ok, err := s.GetString(key)
+	return v, ok && err == nil
 }
 
-func (s *Store) Stats() (keys int, bytes int64, policy string) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	return s.keysUsed, s.bytesUsed, s.evictor.Name()
+// GetString is GET as Redis serves it: a key holding a collection is
+// ErrWrongType, and counts as a hit since the key exists.
+func (s *Store) GetString(key string) ([]byte, bool, error) {
+	s.mu.RLock()
+	e, ok := s.items[key]
+	if ok && e.Expired(time.Now().UnixNano()) {
+		// Lazy expiry has to delete: retry under the write lock.
+		s.mu.RUnlock()
+		return s.getLocking(key)
+	}
+	if !ok {
+		s.mu.RUnlock()
+		s.misses.Add(1)
+		return nil, false, nil
+	}
+	if e.Type != lib.TypeString {
+		s.mu.RUnlock()
+		s.hits.Add(1)
+		return nil, false, ErrWrongType
+	}
+	out := make([]byte, len(e.Value))
+	copy(out, e.Value)
+	full := s.reads.record(e)
+	s.mu.RUnlock()
+
+	s.hits.Add(1)
+	// Drain opportunistically; if a writer holds the lock it drains anyway.
+	if full && s.mu.TryLock() {
+		s.drainReadsLocked()
+		s.unlock()
+	}
+	return out, true, nil
 }
 
-func (s *Store) Get(key string) ([]byte, bool) {
+// getLocking is GetString under the write lock, used when the key may need
+// to expire.
+func (s *Store) getLocking(key string) ([]byte, bool, error) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
+	defer s.unlock()
 
-	e, ok := s.items[key]
+	e, ok := s.lookupLocked(key, time.Now().UnixNano())
 	if !ok {
-		return nil, false
+		s.misses.Add(1)
+		return nil, false, nil
+	}
+	s.hits.Add(1)
+	if e.Type != lib.TypeString {
+		return nil, false, ErrWrongType
 	}
 	s.evictor.OnGet(e)
 
 	out := make([]byte, len(e.Value))
 	copy(out, e.Value)
-	return out, true
+	return out, true, nil
 }
 
+// Set stores val under key without a deadline; an existing TTL is cleared.
 func (s *Store) Set(key string, val []byte) error {
+	return s.set(key, val, 0)
+}
+
+// SetWithTTL stores val under key and expires it after ttl.
+func (s *Store) SetWithTTL(key string, val []byte, ttl time.Duration) error {
+	if ttl <= 0 {
+		return fmt.Errorf("invalid ttl: %s", ttl)
+	}
+	return s.set(key, val, time.Now().Add(ttl).UnixNano())
+}
+
+func (s *Store) set(key string, val []byte, expireAt int64) error {
 	if key == "" {
 		return errors.New("key must not be empty")
 	}
 
 	s.mu.Lock()
-	defer s.mu.Unlock()
+	defer s.unlock()
+	s.drainReadsLocked()
 
-	entryBytes := approxEntryBytes(key, val)
+	return s.setLocked(key, val, expireAt)
+}
+
+// setLocked stores a copy of val under key, making room first.
+func (s *Store) setLocked(key string, val []byte, expireAt int64) error {
+	candidate := &lib.Entry{
+		Key:      key,
+		Value:    append([]byte(nil), val...),
+		ExpireAt: expireAt,
+	}
+	candidate.Bytes = s.entryBytes(candidate)
+	entryBytes := candidate.Bytes
 
 	// If entry itself can't fit into maxBytes, fail fast (otherwise we'd evict everything and still fail).
 	if s.maxBytes > 0 && entryBytes > s.maxBytes {
 		return fmt.Errorf("entry too large: entryBytes=%d > maxBytes=%d", entryBytes, s.maxBytes)
 	}
 
+	s.lookupLocked(key, time.Now().UnixNano()) // drop a stale entry before accounting
+
+	// Make room before touching anything, so a rejected write leaves the store unchanged.
+	if err := s.evictIfNeededLocked(candidate); err != nil {
+		s.stats.RejectedSets++
+		return err
+	}
+
 	if e, ok := s.items[key]; ok {
 		// Update
 		oldBytes := e.Bytes
-		e.Value = append([]byte(nil), val...)
+		s.logicalBytes += candidate.LogicalBytes() - e.LogicalBytes()
+		e.Type, e.Value, e.Object = lib.TypeString, candidate.Value, nil // SET overwrites any type
 		e.Bytes = entryBytes
+		e.Version = s.nextVersionLocked()
 		s.bytesUsed += (e.Bytes - oldBytes)
+		s.setExpireLocked(e, expireAt)
 
 		s.evictor.OnUpdate(e)
 	} else {
 		// Insert
-		e := &lib.Entry{
-			Key:   key,
-			Value: append([]byte(nil), val...),
-			Bytes: entryBytes,
-		}
+		e := candidate
+		e.Version = s.nextVersionLocked()
 		s.items[key] = e
 		s.keysUsed++
 		s.bytesUsed += e.Bytes
+		s.logicalBytes += e.LogicalBytes()
+		s.setExpireLocked(e, expireAt)
 
 		s.evictor.OnAdd(e)
 	}
-
-	s.evictIfNeededLocked()
 	return nil
 }
 
 func (s *Store) Del(key string) bool {
 	s.mu.Lock()
-	defer s.mu.Unlock()
+	defer s.unlock()
 
-	e, ok := s.items[key]
+	e, ok := s.lookupLocked(key, time.Now().UnixNano())
 	if !ok {
 		return false
 	}
@@ -114,24 +240,209 @@ func (s *Store) Del(key string) bool {
 	return true
 }
 
-func (s *Store) Keys() []string {
+// Expire sets a deadline on an existing key. A non-positive ttl deletes the
+// key right away, like Redis EXPIRE with a negative value.
+func (s *Store) Expire(key string, ttl time.Duration) bool {
 	s.mu.Lock()
-	defer s.mu.Unlock()
+	defer s.unlock()
 
+	e, ok := s.lookupLocked(key, time.Now().UnixNano())
+	if !ok {
+		return false
+	}
+	if ttl <= 0 {
+		s.removeEntryLocked(e)
+		return true
+	}
+	s.drainReadsLocked()
+	s.setExpireLocked(e, time.Now().Add(ttl).UnixNano())
+	s.evictor.OnUpdate(e) // volatile policies track keys by TTL
+	return true
+}
+
+// TTL returns the remaining time to live of key, or NoExpiry if the key has
+// no deadline. ok is false when the key does not exist.
+func (s *Store) TTL(key string) (ttl time.Duration, ok bool) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	now := time.Now().UnixNano()
+	e, ok := s.lookupLocked(key, now)
+	if !ok {
+		return 0, false
+	}
+	if e.ExpireAt == 0 {
+		return NoExpiry, true
+	}
+	return time.Duration(e.ExpireAt - now), true
+}
+
+// Persist removes the deadline of key. It reports whether a TTL was removed.
+func (s *Store) Persist(key string) bool {
+	s.mu.Lock()
+	defer s.unlock()
+
+	e, ok := s.lookupLocked(key, time.Now().UnixNano())
+	if !ok || e.ExpireAt == 0 {
+		return false
+	}
+	s.drainReadsLocked()
+	s.setExpireLocked(e, 0)
+	s.evictor.OnUpdate(e) // volatile policies track keys by TTL
+	return true
+}
+
+// Frequency returns the access frequency the evictor keeps for key (like
+// Redis OBJECT FREQ). ok is false when the key does not exist. A wrapper
+// around a policy that doesn't count (volatile-lru, say) can only tell so
+// once it is asked about a key, so a missing key there is just !ok.
+func (s *Store) Frequency(key string) (freq int, ok bool, err error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	fr, isLFU := s.evictor.(eviction.FrequencyReporter)
+	if !isLFU {
+		return 0, false, ErrNoFrequency
+	}
+	s.drainReadsLocked()
+	e, ok := s.lookupLocked(key, time.Now().UnixNano())
+	if !ok {
+		return 0, false, nil
+	}
+	freq, counted := fr.Frequency(e)
+	if !counted {
+		return 0, false, ErrNoFrequency
+	}
+	return freq, true, nil
+}
+
+func (s *Store) Keys() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now().UnixNano()
 	out := make([]string, 0, len(s.items))
-	for k := range s.items {
+	for k, e := range s.items {
+		if e.Expired(now) {
+			continue
+		}
 		out = append(out, k)
 	}
 	return out
 }
 
-func (s *Store) evictIfNeededLocked() {
-	for (s.maxKeys > 0 && s.keysUsed > s.maxKeys) ||
-		(s.maxBytes > 0 && s.bytesUsed > s.maxBytes) {
+// StartActiveExpiry runs the expiry sampler every interval until Close is called.
+// Without it, expired keys are only reclaimed lazily when they are touched.
+func (s *Store) StartActiveExpiry(interval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopExpiry != nil {
+		return
+	}
+	s.stopExpiry = make(chan struct{})
+	s.expiryDone = make(chan struct{})
+
+	go func(stop <-chan struct{}, done chan<- struct{}) {
+		defer close(done)
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-t.C:
+				s.activeExpireCycle()
+			}
+		}
+	}(s.stopExpiry, s.expiryDone)
+}
+
+// Close stops the background expiry goroutine, if any.
+func (s *Store) Close() {
+	s.mu.Lock()
+	stop, done := s.stopExpiry, s.expiryDone
+	s.stopExpiry, s.expiryDone = nil, nil
+	s.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+// activeExpireCycle asks the expirer for due entries and removes them.
+func (s *Store) activeExpireCycle() {
+	s.mu.Lock()
+	defer s.unlock()
+
+	for _, e := range s.expirer.Expired(time.Now().UnixNano(), maxExpiredPerCycle) {
+		// The expirer may hand back an entry that was replaced meanwhile.
+		if cur, ok := s.items[e.Key]; ok && cur == e {
+			s.removeEntryLocked(e)
+			s.countExpirationLocked(e)
+		}
+	}
+}
+
+// lookupLocked returns the live entry for key, lazily deleting it if its TTL has passed.
+func (s *Store) lookupLocked(key string, now int64) (*lib.Entry, bool) {
+	e, ok := s.items[key]
+	if !ok {
+		return nil, false
+	}
+	if e.Expired(now) {
+		s.removeEntryLocked(e)
+		s.countExpirationLocked(e)
+		return nil, false
+	}
+	return e, true
+}
+
+func (s *Store) setExpireLocked(e *lib.Entry, expireAt int64) {
+	e.ExpireAt = expireAt
+	if expireAt == 0 {
+		s.expirer.Untrack(e)
+	} else {
+		s.expirer.Track(e)
+	}
+	s.resizeLocked(e) // expiry bookkeeping is part of the estimate
+}
+
+// evictIfNeededLocked evicts until writing candidate fits the limits.
+// The victim may be the candidate's key itself, which turns the pending
+// update into an insert. New keys are first offered to the evictor's
+// admission policy, if it has one.
+func (s *Store) evictIfNeededLocked(candidate *lib.Entry) error {
+	admitter, _ := s.evictor.(eviction.Admitter)
+	for {
+		keys, bytes := s.keysUsed, s.bytesUsed+candidate.Bytes
+		cur, exists := s.items[candidate.Key]
+		if exists {
+			bytes -= cur.Bytes
+		} else {
+			keys++
+		}
+		var reason Reason
+		switch {
+		case s.maxKeys > 0 && keys > s.maxKeys:
+			reason = ReasonMaxKeys
+		case s.maxBytes > 0 && bytes > s.maxBytes:
+			reason = ReasonMaxBytes
+		default:
+			return nil
+		}
 
 		v := s.evictor.Victim()
 		if v == nil {
-			return
+			return ErrOutOfMemory
+		}
+		if admitter != nil && !exists {
+			// One contest per write: candidate vs. the first victim.
+			if !admitter.Admit(candidate, v) {
+				return ErrNotAdmitted
+			}
+			admitter = nil
 		}
 
 		// Safety: ensure victim is still present.
@@ -142,12 +453,20 @@ func (s *Store) evictIfNeededLocked() {
 			continue
 		}
 		s.removeEntryLocked(cur)
+		s.countEvictionLocked(cur, reason)
 	}
 }
 
+func (s *Store) nextVersionLocked() uint64 {
+	s.version++
+	return s.version
+}
+
 func (s *Store) removeEntryLocked(e *lib.Entry) {
 	delete(s.items, e.Key)
+	s.expirer.Untrack(e)
 	s.keysUsed--
 	s.bytesUsed -= e.Bytes
+	s.logicalBytes -= e.LogicalBytes()
 	s.evictor.OnRemove(e)
 }
@@ -0,0 +1,295 @@
+package store
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/eviction-policies/lib"
+)
+
+// ErrWrongType is returned when a command targets a key holding another type.
+var ErrWrongType = errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")
+
+// Type returns the type of the value stored at key.
+func (s *Store) Type(key string) (lib.Type, bool) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	e, ok := s.lookupLocked(key, time.Now().UnixNano())
+	if !ok {
+		return 0, false
+	}
+	return e.Type, true
+}
+
+// ---- Lists ----
+
+// LPush prepends vals (the last one ends up first) and returns the new length.
+func (s *Store) LPush(key string, vals ...[]byte) (n int, err error) {
+	_, err = s.write(key, lib.TypeList, bytesOf(vals), true, func(e *lib.Entry) {
+		l := e.Object.(*lib.List)
+		for _, v := range vals {
+			l.PushFront(append([]byte(nil), v...))
+		}
+		n = e.Len()
+	})
+	return n, err
+}
+
+// RPush appends vals and returns the new length.
+func (s *Store) RPush(key string, vals ...[]byte) (n int, err error) {
+	_, err = s.write(key, lib.TypeList, bytesOf(vals), true, func(e *lib.Entry) {
+		l := e.Object.(*lib.List)
+		for _, v := range vals {
+			l.PushBack(append([]byte(nil), v...))
+		}
+		n = e.Len()
+	})
+	return n, err
+}
+
+// LPop removes and returns the first element. ok is false for a missing key.
+func (s *Store) LPop(key string) (val []byte, ok bool, err error) {
+	ok, err = s.write(key, lib.TypeList, 0, false, func(e *lib.Entry) {
+		val, _ = e.Object.(*lib.List).PopFront()
+	})
+	return val, ok, err
+}
+
+// LRange returns elements between the inclusive indexes start and stop;
+// negative indexes count from the end, as in Redis.
+func (s *Store) LRange(key string, start, stop int) (out [][]byte, err error) {
+	_, err = s.read(key, lib.TypeList, func(e *lib.Entry) {
+		l := e.Object.(*lib.List)
+		lo, hi, ok := lib.NormalizeRange(start, stop, l.Len())
+		if !ok {
+			return
+		}
+		for i := lo; i < hi; i++ {
+			out = append(out, append([]byte(nil), l.At(i)...))
+		}
+	})
+	return out, err
+}
+
+// ---- Sets ----
+
+// SAdd adds members and returns how many were not already present.
+func (s *Store) SAdd(key string, members ...string) (added int, err error) {
+	_, err = s.write(key, lib.TypeSet, stringsOf(members), true, func(e *lib.Entry) {
+		set := e.Object.(*lib.Set)
+		for _, m := range members {
+			if set.Add(m) {
+				added++
+			}
+		}
+	})
+	return added, err
+}
+
+// SRem removes members and returns how many were present.
+func (s *Store) SRem(key string, members ...string) (removed int, err error) {
+	_, err = s.write(key, lib.TypeSet, 0, false, func(e *lib.Entry) {
+		set := e.Object.(*lib.Set)
+		for _, m := range members {
+			if set.Remove(m) {
+				removed++
+			}
+		}
+	})
+	return removed, err
+}
+
+// SMembers returns the members of the set in no particular order.
+func (s *Store) SMembers(key string) (out []string, err error) {
+	_, err = s.read(key, lib.TypeSet, func(e *lib.Entry) {
+		out = e.Object.(*lib.Set).Members()
+	})
+	return out, err
+}
+
+// SIsMember reports whether member belongs to the set.
+func (s *Store) SIsMember(key, member string) (is bool, err error) {
+	_, err = s.read(key, lib.TypeSet, func(e *lib.Entry) {
+		is = e.Object.(*lib.Set).Has(member)
+	})
+	return is, err
+}
+
+// ---- Hashes ----
+
+// HSet sets field to val and reports whether field is new.
+func (s *Store) HSet(key, field string, val []byte) (created bool, err error) {
+	_, err = s.write(key, lib.TypeHash, int64(len(field)+len(val)), true, func(e *lib.Entry) {
+		created = e.Object.(*lib.Hash).Set(field, append([]byte(nil), val...))
+	})
+	return created, err
+}
+
+// HGet returns the value of field. ok is false if the key or field is missing.
+func (s *Store) HGet(key, field string) (val []byte, ok bool, err error) {
+	_, err = s.read(key, lib.TypeHash, func(e *lib.Entry) {
+		var v []byte
+		if v, ok = e.Object.(*lib.Hash).Get(field); ok {
+			val = append([]byte(nil), v...)
+		}
+	})
+	return val, ok, err
+}
+
+// HDel removes fields and returns how many were present.
+func (s *Store) HDel(key string, fields ...string) (removed int, err error) {
+	_, err = s.write(key, lib.TypeHash, 0, false, func(e *lib.Entry) {
+		h := e.Object.(*lib.Hash)
+		for _, f := range fields {
+			if h.Del(f) {
+				removed++
+			}
+		}
+	})
+	return removed, err
+}
+
+// HGetAll returns a copy of every field and value.
+func (s *Store) HGetAll(key string) (out map[string][]byte, err error) {
+	_, err = s.read(key, lib.TypeHash, func(e *lib.Entry) {
+		h := e.Object.(*lib.Hash)
+		out = make(map[string][]byte, h.Len())
+		h.Range(func(f string, v []byte) {
+			out[f] = append([]byte(nil), v...)
+		})
+	})
+	return out, err
+}
+
+// ---- Sorted sets ----
+
+// ZAdd sets member's score and reports whether member is new. A NaN score
+// is lib.ErrNaNScore.
+func (s *Store) ZAdd(key string, score float64, member string) (added bool, err error) {
+	if math.IsNaN(score) {
+		return false, lib.ErrNaNScore // before write creates the key
+	}
+	_, err = s.write(key, lib.TypeZSet, int64(len(member))+8, true, func(e *lib.Entry) {
+		added, _ = e.Object.(*lib.ZSet).Add(member, score)
+	})
+	return added, err
+}
+
+// ZRange returns members by ascending score between the inclusive ranks
+// start and stop (negative = from the end).
+func (s *Store) ZRange(key string, start, stop int) (out []lib.ZMember, err error) {
+	_, err = s.read(key, lib.TypeZSet, func(e *lib.Entry) {
+		out = e.Object.(*lib.ZSet).Range(start, stop)
+	})
+	return out, err
+}
+
+// ZRank returns member's 0-based rank by ascending score.
+func (s *Store) ZRank(key, member string) (rank int, ok bool, err error) {
+	_, err = s.read(key, lib.TypeZSet, func(e *lib.Entry) {
+		rank, ok = e.Object.(*lib.ZSet).Rank(member)
+	})
+	return rank, ok, err
+}
+
+// ---- helpers ----
+
+// read runs fn on the live entry of type t under key. found is false when
+// the key doesn't exist; a key of another type is ErrWrongType.
+func (s *Store) read(key string, t lib.Type, fn func(e *lib.Entry)) (found bool, err error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	e, ok := s.lookupLocked(key, time.Now().UnixNano())
+	if !ok {
+		s.misses.Add(1)
+		return false, nil
+	}
+	s.hits.Add(1) // the key exists, even if of another type, as in Redis
+	if e.Type != t {
+		return false, ErrWrongType
+	}
+	s.evictor.OnGet(e)
+	fn(e)
+	return true, nil
+}
+
+// write runs fn on the entry of type t under key and re-charges it
+// afterwards. With create, a missing key starts as an empty collection and
+// room for growBy more logical bytes is made first (growBy is an estimate;
+// the real size is charged after fn). A collection left empty is deleted,
+// like in Redis.
+func (s *Store) write(key string, t lib.Type, growBy int64, create bool, fn func(e *lib.Entry)) (found bool, err error) {
+	if key == "" {
+		return false, errors.New("key must not be empty")
+	}
+
+	s.mu.Lock()
+	defer s.unlock()
+	s.drainReadsLocked()
+
+	e, ok := s.lookupLocked(key, time.Now().UnixNano())
+	if ok && e.Type != t {
+		return false, ErrWrongType
+	}
+	if !ok && !create {
+		return false, nil
+	}
+
+	if create {
+		candidate := &lib.Entry{Key: key, Type: t, Object: lib.NewObject(t)}
+		if ok {
+			candidate.Bytes = e.Bytes + growBy
+		} else {
+			candidate.Bytes = s.entryBytes(candidate) + growBy
+		}
+		if err := s.evictIfNeededLocked(candidate); err != nil {
+			s.stats.RejectedSets++
+			return false, err
+		}
+	}
+
+	// Making room may have evicted key itself.
+	inserted := false
+	if e, ok = s.items[key]; !ok {
+		e = &lib.Entry{Key: key, Type: t, Object: lib.NewObject(t)}
+		s.items[key] = e
+		s.keysUsed++
+		s.logicalBytes += e.LogicalBytes()
+		inserted = true
+	}
+
+	oldLogical := e.LogicalBytes()
+	fn(e)
+	e.Version = s.nextVersionLocked()
+	s.logicalBytes += e.LogicalBytes() - oldLogical
+	s.resizeLocked(e)
+
+	if inserted {
+		s.evictor.OnAdd(e)
+	} else {
+		s.evictor.OnUpdate(e)
+	}
+	if e.Len() == 0 {
+		s.removeEntryLocked(e)
+	}
+	return true, nil
+}
+
+func bytesOf(vals [][]byte) int64 {
+	var n int64
+	for _, v := range vals {
+		n += int64(len(v))
+	}
+	return n
+}
+
+func stringsOf(vals []string) int64 {
+	var n int64
+	for _, v := range vals {
+		n += int64(len(v))
+	}
+	return n
+}
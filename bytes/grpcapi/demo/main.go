@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/kvpb"
+)
+
+// Talks to the raw-tcp server's gRPC port:
+//
+//	go run ./bytes/raw-tcp/server
+//	go run ./bytes/grpcapi/demo
+func main() {
+	addr := flag.String("addr", "127.0.0.1:50051", "kv-server gRPC address")
+	flag.Parse()
+
+	conn, err := grpc.NewClient(*addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	c := kvpb.NewKVClient(conn)
+
+	// Every call gets a deadline.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Watch first, so the writes below show up as events.
+	watch, err := c.Watch(ctx)
+	if err != nil {
+		log.Fatalf("watch: %v", err)
+	}
+	if err := watch.Send(&kvpb.WatchRequest{Add: []string{"user:1"}}); err != nil {
+		log.Fatalf("watch: %v", err)
+	}
+	events := make(chan *kvpb.WatchEvent)
+	go func() {
+		for {
+			ev, err := watch.Recv()
+			if err != nil {
+				close(events)
+				return
+			}
+			events <- ev
+		}
+	}()
+	time.Sleep(100 * time.Millisecond) // let the server register the key
+
+	set, err := c.Set(ctx, &kvpb.SetRequest{Key: "user:1", Value: []byte("alice"), Ttl: durationpb.New(time.Minute)})
+	if err != nil {
+		log.Fatalf("set: %v", err)
+	}
+	fmt.Printf("Set user:1 = alice (version %d, ttl 1m)\n", set.Version)
+
+	e, err := c.Get(ctx, &kvpb.GetRequest{Key: "user:1"})
+	if err != nil {
+		log.Fatalf("get: %v", err)
+	}
+	fmt.Printf("Get user:1 = %s (version %d, ttl %s)\n", e.Value, e.Version, e.Ttl.AsDuration().Round(time.Second))
+
+	// Compare-and-set: the first write wins, the stale one is ABORTED.
+	_, err = c.Set(ctx, &kvpb.SetRequest{Key: "user:1", Value: []byte("bob"), ExpectedVersion: proto.Uint64(e.Version)})
+	fmt.Printf("CAS at version %d: %v\n", e.Version, err)
+	_, err = c.Set(ctx, &kvpb.SetRequest{Key: "user:1", Value: []byte("carol"), ExpectedVersion: proto.Uint64(e.Version)})
+	fmt.Printf("CAS at version %d again: %s\n", e.Version, status.Code(err))
+
+	if _, err := c.BatchSet(ctx, &kvpb.BatchSetRequest{Items: []*kvpb.KeyValue{
+		{Key: "user:2", Value: []byte("dave")},
+		{Key: "user:3", Value: []byte("erin")},
+	}}); err != nil {
+		log.Fatalf("batch set: %v", err)
+	}
+	batch, err := c.BatchGet(ctx, &kvpb.BatchGetRequest{Keys: []string{"user:2", "missing", "user:3"}})
+	if err != nil {
+		log.Fatalf("batch get: %v", err)
+	}
+	for _, e := range batch.Entries {
+		fmt.Printf("BatchGet %s = %s\n", e.Key, e.Value)
+	}
+
+	scan, err := c.Scan(ctx, &kvpb.ScanRequest{Prefix: "user:"})
+	if err != nil {
+		log.Fatalf("scan: %v", err)
+	}
+	for {
+		e, err := scan.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Fatalf("scan: %v", err)
+		}
+		fmt.Printf("Scan %s = %s (version %d)\n", e.Key, e.Value, e.Version)
+	}
+
+	del, err := c.Delete(ctx, &kvpb.DeleteRequest{Key: "user:1"})
+	if err != nil {
+		log.Fatalf("delete: %v", err)
+	}
+	fmt.Printf("Delete user:1: %v\n", del.Deleted)
+	if _, err := c.Get(ctx, &kvpb.GetRequest{Key: "user:1"}); status.Code(err) == codes.NotFound {
+		fmt.Println("Get user:1: NotFound")
+	}
+
+	// Set, CAS and Delete of user:1 made three events.
+	for i := 0; i < 3; i++ {
+		ev, ok := <-events
+		if !ok {
+			break
+		}
+		fmt.Printf("Watch: %s %s = %s (version %d)\n", ev.Type, ev.Key, ev.Value, ev.Version)
+	}
+}
@@ -0,0 +1,194 @@
+// Package grpcapi serves a kv.Store over gRPC (service kv.v1.KV, see
+// bytes/kvpb/kv.proto). Go services use the generated kvpb.KVClient for
+// typed calls with deadlines.
+package grpcapi
+
+import (
+	"context"
+	"io"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/kv"
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/kvpb"
+)
+
+const (
+	scanPage = 100 // keys read from the store per lock
+	watchBuf = 256 // events a Watch stream may fall behind by
+)
+
+type server struct {
+	kvpb.UnimplementedKVServer
+	st *kv.Store
+}
+
+// NewServer returns a gRPC server with the KV service for st registered.
+func NewServer(st *kv.Store, opts ...grpc.ServerOption) *grpc.Server {
+	gs := grpc.NewServer(opts...)
+	kvpb.RegisterKVServer(gs, &server{st: st})
+	return gs
+}
+
+func (s *server) Get(ctx context.Context, req *kvpb.GetRequest) (*kvpb.Entry, error) {
+	var e kv.Entry
+	var ok bool
+	s.st.Run(func() { e, ok = s.st.Lookup(req.Key) })
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "key %q not found", req.Key)
+	}
+	return entry(req.Key, e), nil
+}
+
+func (s *server) Set(ctx context.Context, req *kvpb.SetRequest) (*kvpb.SetResponse, error) {
+	if req.Key == "" {
+		return nil, status.Error(codes.InvalidArgument, "key must not be empty")
+	}
+	ttl := req.Ttl.AsDuration()
+	if err := req.Ttl.CheckValid(); req.Ttl != nil && (err != nil || ttl < 0) {
+		return nil, status.Error(codes.InvalidArgument, "ttl must be a non-negative duration")
+	}
+
+	var ver uint64
+	ok := true
+	s.st.Run(func() {
+		if req.ExpectedVersion != nil {
+			ver, ok = s.st.CompareAndSetTTL(req.Key, *req.ExpectedVersion, req.Value, ttl)
+			return
+		}
+		ver = s.st.SetWithTTL(req.Key, req.Value, ttl)
+	})
+	if !ok {
+		return nil, status.Errorf(codes.Aborted, "key %q is not at version %d", req.Key, *req.ExpectedVersion)
+	}
+	return &kvpb.SetResponse{Version: ver}, nil
+}
+
+func (s *server) Delete(ctx context.Context, req *kvpb.DeleteRequest) (*kvpb.DeleteResponse, error) {
+	var deleted bool
+	s.st.Run(func() {
+		if req.ExpectedVersion != nil {
+			deleted = s.st.CompareAndDelete(req.Key, *req.ExpectedVersion)
+			return
+		}
+		deleted = s.st.Del(req.Key)
+	})
+	return &kvpb.DeleteResponse{Deleted: deleted}, nil
+}
+
+func (s *server) BatchGet(ctx context.Context, req *kvpb.BatchGetRequest) (*kvpb.BatchGetResponse, error) {
+	var entries []kv.Entry
+	var found []bool
+	s.st.Run(func() { entries, found = s.st.LookupMany(req.Keys) })
+
+	resp := &kvpb.BatchGetResponse{}
+	for i, k := range req.Keys {
+		if found[i] {
+			resp.Entries = append(resp.Entries, entry(k, entries[i]))
+		}
+	}
+	return resp, nil
+}
+
+func (s *server) BatchSet(ctx context.Context, req *kvpb.BatchSetRequest) (*kvpb.BatchSetResponse, error) {
+	pairs := make([][]byte, 0, 2*len(req.Items))
+	for _, it := range req.Items {
+		if it.Key == "" {
+			return nil, status.Error(codes.InvalidArgument, "key must not be empty")
+		}
+		pairs = append(pairs, []byte(it.Key), it.Value)
+	}
+	s.st.Run(func() { s.st.MSet(pairs) })
+	return &kvpb.BatchSetResponse{}, nil
+}
+
+// Scan reads the store a page at a time, so a long scan never holds the
+// lock for long; keys written meanwhile may or may not be seen. Each page
+// seeks the store's ordered key index, so a full scan is linear in the
+// keys sent.
+func (s *server) Scan(req *kvpb.ScanRequest, stream kvpb.KV_ScanServer) error {
+	after, sent := req.StartAfter, uint32(0)
+	for {
+		if err := stream.Context().Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+		var keys []string
+		var more bool
+		var entries []kv.Entry
+		var found []bool
+		s.st.Run(func() {
+			keys, more = s.st.KeysPage(req.Prefix, after, scanPage)
+			entries, found = s.st.LookupMany(keys)
+		})
+		for i, k := range keys {
+			if !found[i] {
+				continue // deleted since KeysPage
+			}
+			if err := stream.Send(entry(k, entries[i])); err != nil {
+				return err
+			}
+			if sent++; sent == req.Limit {
+				return nil
+			}
+		}
+		if !more {
+			return nil
+		}
+		after = keys[len(keys)-1]
+	}
+}
+
+// Watch adds and removes keys as requests arrive and streams their changes
+// until the call ends or the client falls too far behind. A client that is
+// done changing keys may close its send side and keep receiving.
+func (s *server) Watch(stream kvpb.KV_WatchServer) error {
+	w := s.st.Watch(watchBuf)
+	defer w.Close()
+
+	recvErr := make(chan error, 1)
+	go func() {
+		for {
+			req, err := stream.Recv()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				recvErr <- err
+				return
+			}
+			w.Add(req.Add...)
+			w.Remove(req.Remove...)
+		}
+	}()
+
+	for {
+		select {
+		case err := <-recvErr:
+			return err
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		case ev, ok := <-w.C:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, w.Err().Error())
+			}
+			out := &kvpb.WatchEvent{Type: kvpb.WatchEvent_PUT, Key: ev.Key, Value: ev.Value, Version: ev.Version}
+			if ev.Deleted {
+				out.Type = kvpb.WatchEvent_DELETE
+			}
+			if err := stream.Send(out); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func entry(k string, e kv.Entry) *kvpb.Entry {
+	pe := &kvpb.Entry{Key: k, Value: e.Value, Version: e.Version}
+	if e.TTL != kv.NoExpiry {
+		pe.Ttl = durationpb.New(e.TTL)
+	}
+	return pe
+}
@@ -0,0 +1,282 @@
+// Package httpapi serves a kv.Store over HTTP. Values travel as raw request
+// and response bodies, so they stay binary-safe; listings and errors are
+// JSON.
+//
+//	GET    /keys?prefix=user:&limit=100&cursor=user:42   list keys
+//	GET    /keys/{key}                                   read a value
+//	PUT    /keys/{key}                                   write a value
+//	DELETE /keys/{key}                                   delete a key
+//
+// Every value carries an ETag (its version), so clients can do optimistic
+// updates with If-Match, create-only writes with If-None-Match: *, and
+// conditional GETs. A TTL is set with the X-TTL request header (seconds)
+// and reported in the same header on reads.
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/kv"
+)
+
+const (
+	// TTLHeader carries a key's time to live in whole seconds.
+	TTLHeader = "X-TTL"
+
+	defaultLimit = 100
+	maxLimit     = 1000
+	maxValueSize = 64 << 20
+)
+
+type server struct {
+	st *kv.Store
+}
+
+// NewHandler returns the HTTP API for st.
+func NewHandler(st *kv.Store) http.Handler {
+	s := &server{st: st}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /keys", s.list)
+	mux.HandleFunc("GET /keys/{key...}", s.get)
+	mux.HandleFunc("PUT /keys/{key...}", s.put)
+	mux.HandleFunc("DELETE /keys/{key...}", s.delete)
+
+	// Catch-alls, so every error body is JSON.
+	mux.HandleFunc("/keys", methodNotAllowed("GET"))
+	mux.HandleFunc("/keys/{key...}", methodNotAllowed("GET, PUT, DELETE"))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "not_found", "no such endpoint: "+r.URL.Path)
+	})
+	return mux
+}
+
+// keysPage is the body of GET /keys. NextCursor is passed back as ?cursor=
+// to get the next page; it is empty on the last one.
+type keysPage struct {
+	Keys       []string `json:"keys"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
+
+func (s *server) list(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit := defaultLimit
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxLimit {
+			writeError(w, http.StatusBadRequest, "bad_request", fmt.Sprintf("limit must be between 1 and %d", maxLimit))
+			return
+		}
+		limit = n
+	}
+
+	var page keysPage
+	s.st.Run(func() {
+		var more bool
+		page.Keys, more = s.st.KeysPage(q.Get("prefix"), q.Get("cursor"), limit)
+		if more {
+			page.NextCursor = page.Keys[len(page.Keys)-1]
+		}
+	})
+	if page.Keys == nil {
+		page.Keys = []string{}
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+func (s *server) get(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+
+	var e kv.Entry
+	var ok bool
+	s.st.Run(func() { e, ok = s.st.Lookup(key) })
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", "key not found")
+		return
+	}
+
+	setEntryHeaders(w, e)
+	if inm := r.Header.Get("If-None-Match"); inm != "" && etagMatches(inm, e.Version) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", strconv.Itoa(len(e.Value)))
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		_, _ = w.Write(e.Value)
+	}
+}
+
+func (s *server) put(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+	if key == "" {
+		writeError(w, http.StatusBadRequest, "bad_request", "key must not be empty")
+		return
+	}
+	ttl, err := parseTTL(r.Header.Get(TTLHeader))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+	val, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxValueSize))
+	if err != nil {
+		var tooBig *http.MaxBytesError
+		if errors.As(err, &tooBig) {
+			writeError(w, http.StatusRequestEntityTooLarge, "too_large", fmt.Sprintf("value exceeds %d bytes", maxValueSize))
+			return
+		}
+		writeError(w, http.StatusBadRequest, "bad_request", "reading body: "+err.Error())
+		return
+	}
+
+	ifMatch, ifNoneMatch := r.Header.Get("If-Match"), r.Header.Get("If-None-Match")
+	var ver uint64
+	ok := true
+	s.st.Run(func() {
+		switch {
+		case ifNoneMatch == "*":
+			// create only: "no current version" is version 0
+			ver, ok = s.st.CompareAndSetTTL(key, 0, val, ttl)
+		case ifMatch == "*":
+			// update only: retry until nobody writes in between
+			for {
+				var e kv.Entry
+				if e, ok = s.st.Lookup(key); !ok {
+					return
+				}
+				if ver, ok = s.st.CompareAndSetTTL(key, e.Version, val, ttl); ok {
+					return
+				}
+			}
+		case ifMatch != "":
+			// A version is never 0, and CompareAndSetTTL reads 0 as "absent":
+			// If-Match must not create a missing key (RFC 9110 13.1.1).
+			expected, valid := parseETag(ifMatch)
+			if !valid || expected == 0 {
+				ok = false
+				return
+			}
+			ver, ok = s.st.CompareAndSetTTL(key, expected, val, ttl)
+		default:
+			ver = s.st.SetWithTTL(key, val, ttl)
+		}
+	})
+	if !ok {
+		writeError(w, http.StatusPreconditionFailed, "precondition_failed", "key was modified or does not match the precondition")
+		return
+	}
+
+	w.Header().Set("ETag", etag(ver))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *server) delete(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+	ifMatch := r.Header.Get("If-Match")
+
+	var found, deleted bool
+	s.st.Run(func() {
+		var e kv.Entry
+		if e, found = s.st.Lookup(key); !found {
+			return
+		}
+		switch expected, valid := parseETag(ifMatch); {
+		case ifMatch == "" || ifMatch == "*":
+			deleted = s.st.Del(key)
+		case valid:
+			deleted = expected == e.Version && s.st.CompareAndDelete(key, expected)
+		}
+	})
+	switch {
+	case !found && ifMatch == "":
+		writeError(w, http.StatusNotFound, "not_found", "key not found")
+	case !deleted:
+		writeError(w, http.StatusPreconditionFailed, "precondition_failed", "key was modified or does not match the precondition")
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func setEntryHeaders(w http.ResponseWriter, e kv.Entry) {
+	w.Header().Set("ETag", etag(e.Version))
+	if e.TTL != kv.NoExpiry {
+		// round up, so a live key never reports 0
+		w.Header().Set(TTLHeader, strconv.FormatInt(int64(math.Ceil(e.TTL.Seconds())), 10))
+	}
+}
+
+func etag(ver uint64) string {
+	return `"` + strconv.FormatUint(ver, 10) + `"`
+}
+
+// parseETag reads a strong ETag made by etag. Weak ones (W/"...") never
+// match: If-Match requires strong comparison.
+func parseETag(s string) (uint64, bool) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return 0, false
+	}
+	ver, err := strconv.ParseUint(s[1:len(s)-1], 10, 64)
+	return ver, err == nil
+}
+
+// etagMatches reports whether an If-None-Match list names ver. Weak
+// comparison applies here, so W/ prefixes are ignored.
+func etagMatches(header string, ver uint64) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" {
+			return true
+		}
+		if v, ok := parseETag(tag); ok && v == ver {
+			return true
+		}
+	}
+	return false
+}
+
+func parseTTL(h string) (time.Duration, error) {
+	if h == "" {
+		return 0, nil
+	}
+	secs, err := strconv.ParseInt(h, 10, 64)
+	if err != nil || secs <= 0 || secs > math.MaxInt64/int64(time.Second) {
+		return 0, fmt.Errorf("%s must be a positive number of seconds", TTLHeader)
+	}
+	return time.Duration(secs) * time.Second, nil
+}
+
+func methodNotAllowed(allow string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Allow", allow)
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", r.Method+" not allowed")
+	}
+}
+
+// errorBody is the JSON body of every non-2xx response.
+type errorBody struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func writeError(w http.ResponseWriter, status int, code, msg string) {
+	var body errorBody
+	body.Error.Code, body.Error.Message = code, msg
+	writeJSON(w, status, body)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
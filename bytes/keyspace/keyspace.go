@@ -0,0 +1,82 @@
+// Package keyspace is a string-keyed map split into hash slots, so it can
+// be walked a few slots at a time with a cursor (SCAN) instead of copied
+// whole under a lock (KEYS). A key never changes slot, so a full iteration
+// returns every key present throughout it exactly once; keys added or
+// removed meanwhile may or may not be returned.
+package keyspace
+
+import "hash/fnv"
+
+// Slots is the number of hash slots; a SCAN cursor is a slot number.
+const Slots = 1024
+
+// Map is not safe for concurrent use: the store owning it locks.
+type Map[V any] struct {
+	slots [Slots]map[string]V
+	n     int
+}
+
+func New[V any]() *Map[V] {
+	return &Map[V]{}
+}
+
+// Slot returns the slot k lives in.
+func Slot(k string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(k))
+	return int(h.Sum32() % Slots)
+}
+
+func (m *Map[V]) Get(k string) (V, bool) {
+	v, ok := m.slots[Slot(k)][k]
+	return v, ok
+}
+
+func (m *Map[V]) Set(k string, v V) {
+	slot := &m.slots[Slot(k)]
+	if *slot == nil {
+		*slot = make(map[string]V)
+	}
+	if _, ok := (*slot)[k]; !ok {
+		m.n++
+	}
+	(*slot)[k] = v
+}
+
+func (m *Map[V]) Delete(k string) {
+	slot := m.slots[Slot(k)]
+	if _, ok := slot[k]; ok {
+		delete(slot, k)
+		m.n--
+	}
+}
+
+func (m *Map[V]) Len() int { return m.n }
+
+// Range calls fn for every key until fn returns false.
+func (m *Map[V]) Range(fn func(k string, v V) bool) {
+	for _, slot := range m.slots {
+		for k, v := range slot {
+			if !fn(k, v) {
+				return
+			}
+		}
+	}
+}
+
+// Scan calls fn for every key of whole slots, starting at slot cursor,
+// until at least count keys were visited or the slots ran out. It returns
+// the cursor to continue from: 0 once the last slot was visited.
+func (m *Map[V]) Scan(cursor uint64, count int, fn func(k string, v V)) uint64 {
+	visited := 0
+	for ; cursor < Slots; cursor++ {
+		if visited >= count {
+			return cursor
+		}
+		for k, v := range m.slots[cursor] {
+			fn(k, v)
+			visited++
+		}
+	}
+	return 0
+}
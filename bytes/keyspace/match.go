@@ -0,0 +1,102 @@
+package keyspace
+
+// Match reports whether s matches the Redis-style glob pattern: * matches
+// any run of bytes, ? any one byte, [abc], [^abc] and [a-z] a byte from a
+// set, and \ escapes the next byte. Unlike path.Match, / is not special
+// and a malformed pattern simply doesn't match.
+//
+// Only the last * is backtracked to: on a mismatch it absorbs one more byte
+// and matching resumes after it. Earlier stars never need to move, so the
+// cost is at most len(pattern) * len(s), whatever the number of stars.
+func Match(pattern, s string) bool {
+	ok, _ := match(pattern, s)
+	return ok
+}
+
+// match is Match that also counts the steps it took, for the tests.
+func match(pattern, s string) (ok bool, steps int) {
+	p, i := 0, 0
+	star, mark := -1, 0 // pattern index after the last *, and where in s it started
+	for i < len(s) {
+		steps++
+		if p < len(pattern) && pattern[p] == '*' {
+			p++
+			star, mark = p, i
+			continue
+		}
+		if p < len(pattern) {
+			if n, ok := matchOne(pattern[p:], s[i]); ok {
+				p += n
+				i++
+				continue
+			}
+		}
+		if star < 0 {
+			return false, steps
+		}
+		mark++
+		p, i = star, mark
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern), steps
+}
+
+// matchOne matches c against the single-byte element that starts pattern
+// (anything but *) and returns the element's length in the pattern.
+func matchOne(pattern string, c byte) (n int, ok bool) {
+	switch pattern[0] {
+	case '?':
+		return 1, true
+	case '[':
+		return matchClass(pattern, c)
+	case '\\':
+		if len(pattern) > 1 {
+			return 2, pattern[1] == c
+		}
+	}
+	return 1, pattern[0] == c
+}
+
+// matchClass matches c against the set that starts pattern with '[' and
+// returns the set's length up to the closing ']'. An unterminated set
+// matches nothing.
+func matchClass(pattern string, c byte) (n int, ok bool) {
+	i := 1
+	negate := i < len(pattern) && pattern[i] == '^'
+	if negate {
+		i++
+	}
+	matched := false
+	for {
+		if i >= len(pattern) {
+			return 0, false // no closing ]
+		}
+		if pattern[i] == ']' {
+			return i + 1, matched != negate
+		}
+		lo := pattern[i]
+		if lo == '\\' && i+1 < len(pattern) {
+			i++
+			lo = pattern[i]
+		}
+		i++
+		hi := lo
+		if i+1 < len(pattern) && pattern[i] == '-' && pattern[i+1] != ']' {
+			i++
+			hi = pattern[i]
+			if hi == '\\' && i+1 < len(pattern) {
+				i++
+				hi = pattern[i]
+			}
+			i++
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+		}
+		if lo <= c && c <= hi {
+			matched = true
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package keyspace
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"", "", true},
+		{"", "a", false},
+		{"abc", "abc", true},
+		{"abc", "abd", false},
+		{"abc", "ab", false},
+
+		{"*", "", true},
+		{"*", "a/b", true},
+		{"user:*", "user:1", true},
+		{"user:*", "users", false},
+		{"*:1", "user:1", true},
+		{"a*b*c", "aXbYc", true},
+		{"a*b*c", "aXbY", false},
+		{"a**b", "ab", true},
+		{"*a*", "bab", true},
+
+		{"?", "a", true},
+		{"?", "", false},
+		{"?", "ab", false},
+		{"h?llo", "hello", true},
+		{"h?llo", "hllo", false},
+
+		{"h[ae]llo", "hallo", true},
+		{"h[ae]llo", "hillo", false},
+		{"h[^e]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[^ab]llo", "hbllo", false},
+		{"[a-c]x", "bx", true},
+		{"[a-c]x", "dx", false},
+		{"[c-a]x", "bx", true}, // reversed range
+		{"[^a-c]x", "dx", true},
+		{"[0-9][0-9]", "42", true},
+		{"[a-]", "-", true}, // - before ] is literal
+		{"[]", "a", false},
+		{`[\]]`, "]", true},
+		{`[\-a]`, "-", true},
+
+		{`\*`, "*", true},
+		{`\*`, "a", false},
+		{`\?`, "?", true},
+		{`\[a]`, "[a]", true},
+		{`a\\b`, `a\b`, true},
+		{`a\`, `a\`, true}, // trailing \ is literal
+
+		// an unterminated set matches nothing
+		{"[abc", "a", false},
+		{"[abc", "[abc", false},
+		{"a*[b", "axb", false},
+		{"[^", "x", false},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.pattern, tt.s); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+}
+
+// Backtracking into every split point made each extra * multiply the work;
+// a SCAN runs Match on every key it visits while holding the store's lock.
+func TestMatchManyStars(t *testing.T) {
+	pattern := strings.Repeat("*a", 20) + "*b"
+	s := strings.Repeat("a", 1000)
+	ok, steps := match(pattern, s)
+	if ok {
+		t.Fatalf("Match(%q, %d a's) = true", pattern, len(s))
+	}
+	if limit := (len(pattern) + 1) * (len(s) + 1); steps > limit {
+		t.Fatalf("took %d steps, want at most %d", steps, limit)
+	}
+	if !Match(pattern, s+"b") {
+		t.Fatalf("Match(%q, a's then b) = false", pattern)
+	}
+}
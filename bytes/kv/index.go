@@ -0,0 +1,79 @@
+package kv
+
+import "math/rand/v2"
+
+// index keeps every key of the store in lexical order, so KeysPage seeks
+// to its cursor instead of sorting the keyspace: a skiplist, as Redis uses
+// for sorted sets, with O(log n) inserts, removes and seeks. The store's
+// lock protects it.
+type index struct {
+	head  node // sentinel before the first key
+	level int  // levels in use, at most maxLevel
+}
+
+const (
+	maxLevel = 32
+	pLevel   = 4 // 1 in pLevel nodes reaches the next level
+)
+
+type node struct {
+	key  string
+	next []*node // next[i] is the following node at level i
+}
+
+func newIndex() *index {
+	return &index{head: node{next: make([]*node, maxLevel)}, level: 1}
+}
+
+// path fills prev[i] with the last node at level i whose key is below k.
+func (x *index) path(k string, prev *[maxLevel]*node) {
+	n := &x.head
+	for i := x.level - 1; i >= 0; i-- {
+		for n.next[i] != nil && n.next[i].key < k {
+			n = n.next[i]
+		}
+		prev[i] = n
+	}
+}
+
+// insert adds k, which must not be in the index.
+func (x *index) insert(k string) {
+	var prev [maxLevel]*node
+	x.path(k, &prev)
+
+	level := 1
+	for level < maxLevel && rand.IntN(pLevel) == 0 {
+		level++
+	}
+	for ; x.level < level; x.level++ {
+		prev[x.level] = &x.head
+	}
+	n := &node{key: k, next: make([]*node, level)}
+	for i := 0; i < level; i++ {
+		n.next[i] = prev[i].next[i]
+		prev[i].next[i] = n
+	}
+}
+
+func (x *index) remove(k string) {
+	var prev [maxLevel]*node
+	x.path(k, &prev)
+
+	n := prev[0].next[0]
+	if n == nil || n.key != k {
+		return
+	}
+	for i := range n.next {
+		prev[i].next[i] = n.next[i]
+	}
+	for x.level > 1 && x.head.next[x.level-1] == nil {
+		x.level--
+	}
+}
+
+// seek returns the node of the first key >= k, or nil.
+func (x *index) seek(k string) *node {
+	var prev [maxLevel]*node
+	x.path(k, &prev)
+	return prev[0].next[0]
+}
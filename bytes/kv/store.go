@@ -0,0 +1,393 @@
+// Package kv is the in-memory store shared by the raw-tcp server and the
+// HTTP API: binary-safe values with versions for optimistic concurrency and
+// optional TTLs.
+package kv
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/keyspace"
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/rediscompat"
+)
+
+// The numeric errors are shared with the eviction-policies store.
+var (
+	ErrNotInteger = rediscompat.ErrNotInteger
+	ErrNotFloat   = rediscompat.ErrNotFloat
+	ErrOverflow   = rediscompat.ErrOverflow
+	ErrNaNOrInf   = rediscompat.ErrNaNOrInf
+)
+
+// item is a value plus the version it was last written at. Values are
+// replaced, never modified in place, so readers may share val.
+type item struct {
+	val      []byte
+	ver      uint64
+	expireAt int64 // unix nanos, 0 = no TTL
+}
+
+func (it item) expired(now int64) bool {
+	return it.expireAt > 0 && now >= it.expireAt
+}
+
+type Store struct {
+	// exec is held shared by Run and exclusively by Atomic, so a
+	// transaction never interleaves with other clients' commands.
+	exec sync.RWMutex
+
+	mu       sync.RWMutex
+	m        *keyspace.Map[item] // slotted, so SCAN can walk it a few slots at a time
+	keys     *index              // the keys of m in order, for KeysPage
+	volatile map[string]struct{} // keys with a TTL, sampled by active expiry
+	ver      uint64              // last version handed out; one counter, so a recreated key never reuses one
+	watchers map[string]map[*Watcher]struct{}
+
+	stopExpiry chan struct{}
+}
+
+func NewStore() *Store {
+	return &Store{
+		m:        keyspace.New[item](),
+		keys:     newIndex(),
+		volatile: make(map[string]struct{}),
+		watchers: make(map[string]map[*Watcher]struct{}),
+	}
+}
+
+// Run runs one command. Commands run concurrently with each other but
+// never inside an Atomic.
+func (s *Store) Run(fn func()) {
+	s.exec.RLock()
+	defer s.exec.RUnlock()
+	fn()
+}
+
+// Atomic runs fn with every other Run held off (MULTI/EXEC).
+func (s *Store) Atomic(fn func()) {
+	s.exec.Lock()
+	defer s.exec.Unlock()
+	fn()
+}
+
+// getLocked returns the live item at k; an expired one reads as missing.
+func (s *Store) getLocked(k string, now int64) (item, bool) {
+	it, ok := s.m.Get(k)
+	if !ok || it.expired(now) {
+		return item{}, false
+	}
+	return it, true
+}
+
+// putLocked writes a copy of v with the given deadline and gives it a
+// fresh version.
+func (s *Store) putLocked(k string, v []byte, expireAt int64) uint64 {
+	s.ver++
+	it := item{val: append([]byte{}, v...), ver: s.ver, expireAt: expireAt}
+	if _, ok := s.m.Get(k); !ok {
+		s.keys.insert(k)
+	}
+	s.m.Set(k, it)
+	if expireAt > 0 {
+		s.volatile[k] = struct{}{}
+	} else {
+		delete(s.volatile, k)
+	}
+	s.notifyLocked(Event{Key: k, Value: it.val, Version: it.ver})
+	return s.ver
+}
+
+func (s *Store) deleteLocked(k string) {
+	if _, ok := s.m.Get(k); !ok {
+		return
+	}
+	s.m.Delete(k)
+	s.keys.remove(k)
+	delete(s.volatile, k)
+	s.ver++ // a delete is a change too, ordered with the writes
+	s.notifyLocked(Event{Key: k, Deleted: true, Version: s.ver})
+}
+
+// Set stores v without a TTL, clearing any previous one.
+func (s *Store) Set(k string, v []byte) {
+	s.mu.Lock()
+	s.putLocked(k, v, 0)
+	s.mu.Unlock()
+}
+
+// SetWithTTL stores v and expires it after ttl (0 = never). It returns the
+// new version.
+func (s *Store) SetWithTTL(k string, v []byte, ttl time.Duration) uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.putLocked(k, v, deadline(ttl))
+}
+
+// Get returns the value at k; callers must not modify it.
+func (s *Store) Get(k string) ([]byte, bool) {
+	s.mu.RLock()
+	it, ok := s.getLocked(k, time.Now().UnixNano())
+	s.mu.RUnlock()
+	return it.val, ok
+}
+
+// GetVersion returns the value at k and the version to pass to CompareAndSet.
+func (s *Store) GetVersion(k string) ([]byte, uint64, bool) {
+	s.mu.RLock()
+	it, ok := s.getLocked(k, time.Now().UnixNano())
+	s.mu.RUnlock()
+	return it.val, it.ver, ok
+}
+
+// Entry is a snapshot of one key, read under a single lock.
+type Entry struct {
+	Value   []byte // must not be modified
+	Version uint64
+	TTL     time.Duration // NoExpiry if the key has no deadline
+}
+
+// Lookup returns the value, version and TTL of k together.
+func (s *Store) Lookup(k string) (Entry, bool) {
+	now := time.Now().UnixNano()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lookupLocked(k, now)
+}
+
+// LookupMany is Lookup for several keys under one lock: a snapshot no
+// concurrent MSet can tear.
+func (s *Store) LookupMany(keys []string) (entries []Entry, found []bool) {
+	entries, found = make([]Entry, len(keys)), make([]bool, len(keys))
+	now := time.Now().UnixNano()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for i, k := range keys {
+		entries[i], found[i] = s.lookupLocked(k, now)
+	}
+	return entries, found
+}
+
+func (s *Store) lookupLocked(k string, now int64) (Entry, bool) {
+	it, ok := s.getLocked(k, now)
+	if !ok {
+		return Entry{}, false
+	}
+	e := Entry{Value: it.val, Version: it.ver, TTL: NoExpiry}
+	if it.expireAt > 0 {
+		e.TTL = time.Duration(it.expireAt - now)
+	}
+	return e, true
+}
+
+// SetMode is the existence condition of a SET.
+type SetMode int
+
+const (
+	SetAlways    SetMode = iota
+	SetIfAbsent          // NX
+	SetIfPresent         // XX
+)
+
+// SetIf writes v when mode allows and returns the previous value, if any,
+// whether or not the write happened (SET ... GET).
+func (s *Store) SetIf(k string, v []byte, mode SetMode) (old []byte, existed, written bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	it, existed := s.getLocked(k, time.Now().UnixNano())
+	if (mode == SetIfAbsent && existed) || (mode == SetIfPresent && !existed) {
+		return it.val, existed, false
+	}
+	s.putLocked(k, v, 0)
+	return it.val, existed, true
+}
+
+// CompareAndSet writes v only if k is still at version expected (0 means k
+// must not exist) and returns the new version.
+func (s *Store) CompareAndSet(k string, expected uint64, v []byte) (uint64, bool) {
+	return s.CompareAndSetTTL(k, expected, v, 0)
+}
+
+// CompareAndSetTTL is CompareAndSet that also sets a TTL (0 = none).
+func (s *Store) CompareAndSetTTL(k string, expected uint64, v []byte, ttl time.Duration) (uint64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	it, _ := s.getLocked(k, time.Now().UnixNano())
+	if it.ver != expected {
+		return 0, false
+	}
+	return s.putLocked(k, v, deadline(ttl)), true
+}
+
+// CompareAndDelete deletes k only if it is at version expected.
+func (s *Store) CompareAndDelete(k string, expected uint64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	it, ok := s.getLocked(k, time.Now().UnixNano())
+	if !ok || it.ver != expected {
+		return false
+	}
+	s.deleteLocked(k)
+	return true
+}
+
+// MGet reads every key under one lock, so the values are a snapshot no
+// concurrent MSET can tear.
+func (s *Store) MGet(keys []string) (vals [][]byte, found []bool) {
+	vals, found = make([][]byte, len(keys)), make([]bool, len(keys))
+	now := time.Now().UnixNano()
+	s.mu.RLock()
+	for i, k := range keys {
+		var it item
+		it, found[i] = s.getLocked(k, now)
+		vals[i] = it.val
+	}
+	s.mu.RUnlock()
+	return vals, found
+}
+
+// MSet writes key/value pairs (kv = k1, v1, k2, v2, ...) under one lock, so
+// other clients see all of them or none.
+func (s *Store) MSet(kv [][]byte) {
+	s.mu.Lock()
+	for i := 0; i+1 < len(kv); i += 2 {
+		s.putLocked(string(kv[i]), kv[i+1], 0)
+	}
+	s.mu.Unlock()
+}
+
+// MSetNX is MSet that writes nothing if any of the keys exists.
+func (s *Store) MSetNX(kv [][]byte) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UnixNano()
+	for i := 0; i < len(kv); i += 2 {
+		if _, ok := s.getLocked(string(kv[i]), now); ok {
+			return false
+		}
+	}
+	for i := 0; i+1 < len(kv); i += 2 {
+		s.putLocked(string(kv[i]), kv[i+1], 0)
+	}
+	return true
+}
+
+func (s *Store) Del(k string) bool {
+	s.mu.Lock()
+	_, ok := s.getLocked(k, time.Now().UnixNano())
+	s.deleteLocked(k)
+	s.mu.Unlock()
+	return ok
+}
+
+// IncrBy adds delta to the integer at k under one lock, so concurrent
+// counters don't lose updates. A missing key counts as 0; a TTL is kept.
+func (s *Store) IncrBy(k string, delta int64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var n int64
+	it, ok := s.getLocked(k, time.Now().UnixNano())
+	if ok {
+		var err error
+		if n, err = strconv.ParseInt(string(it.val), 10, 64); err != nil {
+			return 0, ErrNotInteger
+		}
+	}
+	if (delta > 0 && n > math.MaxInt64-delta) || (delta < 0 && n < math.MinInt64-delta) {
+		return 0, ErrOverflow
+	}
+	n += delta
+	s.putLocked(k, strconv.AppendInt(nil, n, 10), it.expireAt)
+	return n, nil
+}
+
+// IncrByFloat adds delta to the number at k under one lock.
+func (s *Store) IncrByFloat(k string, delta float64) (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var f float64
+	it, ok := s.getLocked(k, time.Now().UnixNano())
+	if ok {
+		var err error
+		if f, err = strconv.ParseFloat(string(it.val), 64); err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return 0, ErrNotFloat
+		}
+	}
+	f += delta
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, ErrNaNOrInf
+	}
+	s.putLocked(k, strconv.AppendFloat(nil, f, 'f', -1, 64), it.expireAt)
+	return f, nil
+}
+
+func (s *Store) Keys() []string {
+	now := time.Now().UnixNano()
+	s.mu.RLock()
+	keys := make([]string, 0, s.m.Len())
+	s.m.Range(func(k string, it item) bool {
+		if !it.expired(now) {
+			keys = append(keys, k)
+		}
+		return true
+	})
+	s.mu.RUnlock()
+	return keys
+}
+
+// Scan is one step of a SCAN iteration: it returns the live keys matching
+// the glob match ("" = all) among at least count keys from the cursor on,
+// and the cursor for the next step, 0 when the iteration is done. Keys
+// present throughout the iteration are returned exactly once; unlike Keys,
+// each step holds the lock only for the slots it visits.
+func (s *Store) Scan(cursor uint64, match string, count int) (next uint64, keys []string) {
+	now := time.Now().UnixNano()
+	s.mu.RLock()
+	next = s.m.Scan(cursor, count, func(k string, it item) {
+		if !it.expired(now) && (match == "" || keyspace.Match(match, k)) {
+			keys = append(keys, k)
+		}
+	})
+	s.mu.RUnlock()
+	return next, keys
+}
+
+// KeysPage returns up to limit keys with prefix in lexical order, starting
+// after the key after (empty = from the start), and whether more follow.
+// Keys written between pages may be missed or seen, but never twice. The
+// ordered index makes a page O(log n + limit), whatever the keyspace size.
+func (s *Store) KeysPage(prefix, after string, limit int) (keys []string, more bool) {
+	from := prefix
+	if after >= from {
+		from = after + "\x00" // the smallest key above after
+	}
+	now := time.Now().UnixNano()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for n := s.keys.seek(from); n != nil && strings.HasPrefix(n.key, prefix); n = n.next[0] {
+		if it, _ := s.m.Get(n.key); it.expired(now) {
+			continue
+		}
+		if len(keys) == limit {
+			return keys, true
+		}
+		keys = append(keys, n.key)
+	}
+	return keys, false
+}
+
+func deadline(ttl time.Duration) int64 {
+	if ttl <= 0 {
+		return 0
+	}
+	return time.Now().Add(ttl).UnixNano()
+}
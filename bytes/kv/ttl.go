@@ -0,0 +1,98 @@
+package kv
+
+import (
+	"time"
+
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/rediscompat"
+)
+
+// NoExpiry is returned by TTL for keys that exist but have no deadline.
+const NoExpiry time.Duration = -1
+
+// TTL returns the time left before k expires, or NoExpiry.
+func (s *Store) TTL(k string) (time.Duration, bool) {
+	e, ok := s.Lookup(k)
+	return e.TTL, ok
+}
+
+// Expire sets a TTL on an existing key; ttl <= 0 removes it (PERSIST).
+// Like any other change it gets a new version (so ETags change) and is
+// reported to watchers, which also aborts a transaction WATCHing k, as
+// EXPIRE does in Redis.
+func (s *Store) Expire(k string, ttl time.Duration) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	it, ok := s.getLocked(k, time.Now().UnixNano())
+	if !ok {
+		return false
+	}
+	s.ver++
+	it.ver = s.ver
+	it.expireAt = deadline(ttl)
+	s.m.Set(k, it)
+	if it.expireAt > 0 {
+		s.volatile[k] = struct{}{}
+	} else {
+		delete(s.volatile, k)
+	}
+	s.notifyLocked(Event{Key: k, Value: it.val, Version: it.ver})
+	return true
+}
+
+// StartActiveExpiry reclaims expired keys every interval, so keys nobody
+// reads again don't hold memory forever. Reads already hide them.
+func (s *Store) StartActiveExpiry(interval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopExpiry != nil {
+		return
+	}
+	s.stopExpiry = make(chan struct{})
+	go func(stop <-chan struct{}) {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-t.C:
+				s.expireCycle()
+			}
+		}
+	}(s.stopExpiry)
+}
+
+// Close stops active expiry.
+func (s *Store) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopExpiry != nil {
+		close(s.stopExpiry)
+		s.stopExpiry = nil
+	}
+}
+
+func (s *Store) expireCycle() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UnixNano()
+	for round := 0; round < rediscompat.ExpireMaxRounds; round++ {
+		sampled, expired := 0, 0
+		// map iteration starts at a random position: a cheap random sample
+		for k := range s.volatile {
+			if sampled == rediscompat.ExpireSampleSize {
+				break
+			}
+			sampled++
+			if it, _ := s.m.Get(k); it.expired(now) {
+				s.deleteLocked(k)
+				expired++
+			}
+		}
+		if expired*100 <= sampled*rediscompat.ExpireRepeatPercent {
+			return
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package kv
+
+import "errors"
+
+// ErrWatcherLagged is reported by a Watcher whose consumer fell so far
+// behind that its buffer filled up; events after that were dropped.
+var ErrWatcherLagged = errors.New("watcher fell behind")
+
+// Event is one change to a watched key.
+type Event struct {
+	Key     string
+	Deleted bool   // deleted or expired; Value is nil
+	Value   []byte // must not be modified
+	Version uint64 // new version; a delete gets one too
+}
+
+// Watcher receives an Event for every write, TTL change or delete of its
+// keys; a TTL change carries the unchanged value. A key that expires is
+// reported once active expiry reclaims it.
+type Watcher struct {
+	s    *Store
+	C    <-chan Event
+	c    chan Event
+	keys map[string]struct{}
+	err  error // set when C is closed
+}
+
+// Watch returns a Watcher whose channel buffers up to buf events. It
+// watches no keys until Add.
+func (s *Store) Watch(buf int) *Watcher {
+	c := make(chan Event, buf)
+	return &Watcher{s: s, C: c, c: c, keys: make(map[string]struct{})}
+}
+
+// Add starts watching keys; only changes made after Add returns are seen.
+func (w *Watcher) Add(keys ...string) {
+	w.s.mu.Lock()
+	defer w.s.mu.Unlock()
+	if w.err != nil {
+		return
+	}
+	for _, k := range keys {
+		if _, ok := w.keys[k]; ok {
+			continue
+		}
+		w.keys[k] = struct{}{}
+		if w.s.watchers[k] == nil {
+			w.s.watchers[k] = make(map[*Watcher]struct{})
+		}
+		w.s.watchers[k][w] = struct{}{}
+	}
+}
+
+// Remove stops watching keys.
+func (w *Watcher) Remove(keys ...string) {
+	w.s.mu.Lock()
+	defer w.s.mu.Unlock()
+	for _, k := range keys {
+		w.unwatchLocked(k)
+	}
+}
+
+// Close stops the watcher and closes C.
+func (w *Watcher) Close() {
+	w.s.mu.Lock()
+	defer w.s.mu.Unlock()
+	w.closeLocked(nil)
+}
+
+// Err reports why C was closed: nil after Close, ErrWatcherLagged if the
+// consumer fell behind.
+func (w *Watcher) Err() error {
+	w.s.mu.RLock()
+	defer w.s.mu.RUnlock()
+	if errors.Is(w.err, errWatcherClosed) {
+		return nil
+	}
+	return w.err
+}
+
+var errWatcherClosed = errors.New("watcher closed")
+
+func (w *Watcher) closeLocked(err error) {
+	if w.err != nil {
+		return
+	}
+	for k := range w.keys {
+		w.unwatchLocked(k)
+	}
+	if err == nil {
+		err = errWatcherClosed
+	}
+	w.err = err
+	close(w.c)
+}
+
+func (w *Watcher) unwatchLocked(k string) {
+	delete(w.keys, k)
+	if ws := w.s.watchers[k]; ws != nil {
+		delete(ws, w)
+		if len(ws) == 0 {
+			delete(w.s.watchers, k)
+		}
+	}
+}
+
+// notifyLocked tells k's watchers about a change. It never blocks the
+// writer: a watcher with a full buffer is closed instead.
+func (s *Store) notifyLocked(ev Event) {
+	for w := range s.watchers[ev.Key] {
+		select {
+		case w.c <- ev:
+		default:
+			w.closeLocked(ErrWatcherLagged)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/client"
+)
+
+// Measures SET/GET throughput at several pipeline depths. Compare reply
+// batching with the old flush-per-reply behaviour:
+//
+//	go run ./bytes/raw-tcp/server               # batched replies
+//	go run ./bytes/raw-tcp/bench
+//
+//	go run ./bytes/raw-tcp/server -flush-each   # one write per reply
+//	go run ./bytes/raw-tcp/bench
+//
+// Point -addr at the consistent-hashing proxy to measure it the same way.
+func main() {
+	addr := flag.String("addr", "127.0.0.1:6380", "server or proxy address")
+	requests := flag.Int("n", 100000, "requests per pipeline depth")
+	clients := flag.Int("c", 10, "concurrent connections")
+	depths := flag.String("P", "1,16,64", "pipeline depths to run")
+	flag.Parse()
+
+	c := client.New(client.Options{Addr: *addr, PoolSize: *clients})
+	defer c.Close()
+	ctx := context.Background()
+	if err := c.Ping(ctx); err != nil {
+		log.Fatalf("cannot reach %s: %v", *addr, err)
+	}
+
+	fmt.Printf("%d requests, %d connections, %s\n\n", *requests, *clients, *addr)
+	fmt.Printf("%-10s %14s %12s\n", "PIPELINE", "REQUESTS/SEC", "ELAPSED")
+	for _, d := range strings.Split(*depths, ",") {
+		depth, err := strconv.Atoi(d)
+		if err != nil || depth < 1 {
+			log.Fatalf("bad pipeline depth %q", d)
+		}
+		elapsed, err := run(ctx, c, *requests, *clients, depth)
+		if err != nil {
+			log.Fatalf("pipeline %d: %v", depth, err)
+		}
+		fmt.Printf("%-10d %14.0f %12s\n", depth, float64(*requests)/elapsed.Seconds(), elapsed.Round(time.Millisecond))
+	}
+}
+
+// run splits n requests, alternating SET and GET, over the clients, each
+// sending depth commands per round trip.
+func run(ctx context.Context, c *client.Client, n, clients, depth int) (time.Duration, error) {
+	var wg sync.WaitGroup
+	errs := make(chan error, clients)
+	start := time.Now()
+	for i := 0; i < clients; i++ {
+		share := n / clients
+		if i < n%clients {
+			share++
+		}
+		wg.Add(1)
+		go func(id, share int) {
+			defer wg.Done()
+			p := c.Pipeline()
+			for sent := 0; sent < share; {
+				for ; p.Len() < depth && sent < share; sent++ {
+					key := "bench:" + strconv.Itoa(id) + ":" + strconv.Itoa(sent%1000)
+					if sent%2 == 0 {
+						p.Do("SET", key, "xxxxxxxxxxxxxxxx")
+					} else {
+						p.Do("GET", key)
+					}
+				}
+				replies, err := p.Exec(ctx)
+				if err != nil {
+					errs <- err
+					return
+				}
+				for _, r := range replies {
+					if err := r.Err(); err != nil {
+						errs <- err
+						return
+					}
+				}
+			}
+		}(i, share)
+	}
+	wg.Wait()
+	close(errs)
+	return time.Since(start), <-errs
+}
@@ -1,156 +1,234 @@
 package main
 
 import (
-	"bufio"
-	"fmt"
+	"errors"
+	"flag"
 	"log"
+	"math"
 	"net"
+	"net/http"
+	"strconv"
 	"strings"
-	"sync"
-)
-
-type Store struct {
-	mu sync.RWMutex
-	m  map[string]string
-}
-
-func NewStore() *Store {
-	return &Store{m: make(map[string]string)}
-}
-
-func (s *Store) Set(k, v string) {
-	s.mu.Lock()
-	s.m[k] = v
-	s.mu.Unlock()
-}
+	"time"
 
-func (s *Store) Get(k string) (string, bool) {
-	s.mu.RLock()
-	v, ok := s.m[k]
-	s.mu.RUnlock()
-	return v, ok
-}
-
-func (s *Store) Del(k string) bool {
-	s.mu.Lock()
-	_, ok := s.m[k]
-	if ok {
-		delete(s.m, k)
-	}
-	s.mu.Unlock()
-	return ok
-}
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/command"
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/grpcapi"
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/httpapi"
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/kv"
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/resp"
+)
 
-func (s *Store) Keys() []string {
-	s.mu.RLock()
-	keys := make([]string, 0, len(s.m))
-	for k := range s.m {
-		keys = append(keys, k)
+// parseSetOptions reads the options after SET key value.
+func parseSetOptions(opts [][]byte) (mode kv.SetMode, get bool, err error) {
+	for _, o := range opts {
+		switch opt := strings.ToUpper(string(o)); {
+		case opt == "GET" && !get:
+			get = true
+		case (opt == "NX" || opt == "XX") && mode != kv.SetAlways:
+			return 0, false, errors.New("ERR syntax error: NX and XX are exclusive")
+		case opt == "NX":
+			mode = kv.SetIfAbsent
+		case opt == "XX":
+			mode = kv.SetIfPresent
+		default:
+			return 0, false, errors.New("ERR syntax error")
+		}
 	}
-	s.mu.RUnlock()
-	return keys
+	return mode, get, nil
 }
 
-func writeLine(w *bufio.Writer, line string) error {
-	_, err := w.WriteString(line + "\n")
+// writeInt applies an integer increment and replies with the new value or the error.
+func writeInt(w *resp.Writer, st *kv.Store, key string, delta int64) {
+	n, err := st.IncrBy(key, delta)
 	if err != nil {
-		return err
+		w.WriteError(err.Error())
+		return
 	}
-	return w.Flush()
+	w.WriteInt(n)
 }
 
-func handleConn(conn net.Conn, st *Store) {
-	defer conn.Close()
+// flushEach turns off reply batching, to measure what it buys (see bench).
+var flushEach bool
 
-	r := bufio.NewReader(conn)
-	w := bufio.NewWriter(conn)
+func handleConn(conn net.Conn, cmds *command.Table, st *kv.Store) {
+	defer conn.Close()
 
-	_ = writeLine(w, "+OK kv-server ready")
+	r := resp.NewReader(conn)
+	w := resp.NewWriter(conn)
 
+	tx := &txState{}
+	defer tx.reset() // unregisters the watches
+	c := &command.Conn{W: w, Data: tx}
 	for {
-		line, err := r.ReadString('\n')
+		args, err := r.ReadCommand()
 		if err != nil {
+			if errors.Is(err, resp.ErrProtocol) {
+				w.WriteError(err.Error())
+				_ = w.Flush()
+			}
 			// client disconnected
 			return
 		}
-		line = strings.TrimSpace(line)
-		if line == "" {
+		if len(args) == 0 {
 			continue
 		}
 
-		// Very simple parser:
-		// - split into tokens by spaces
-		// - SET uses: SET key <rest-of-line as value>
-		parts := strings.Split(line, " ")
-		cmd := strings.ToUpper(parts[0])
+		switch spec, ok := cmds.Lookup(args[0]); {
+		case ok && spec.Has(command.NoMulti):
+			// Connection and transaction commands take the locks they need.
+			cmds.Dispatch(c, args)
+		case tx.queuing:
+			tx.queue(c, cmds, args)
+		default:
+			st.Run(func() { cmds.Dispatch(c, args) })
+		}
+		if c.Quit {
+			_ = w.Flush()
+			return
+		}
 
-		switch cmd {
-		case "PING":
-			_ = writeLine(w, "+PONG")
+		// A pipelining client has more commands waiting in the read buffer:
+		// run them first and send all their replies in one write.
+		if r.Buffered() > 0 && !flushEach {
+			continue
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
 
-		case "QUIT":
-			_ = writeLine(w, "+BYE")
+// newTable builds the commands the server serves from st.
+func newTable(st *kv.Store) *command.Table {
+	t := command.NewTable()
+	t.Handle("PING", command.Ping)
+	t.Handle("ECHO", command.Echo)
+	t.Handle("QUIT", command.Quit)
+	t.Handle("HELLO", command.Hello("server", "kv-server", "version", "0.1.0", "mode", "standalone", "role", "master"))
+	t.Handle("SELECT", command.OK)
+	t.Handle("CLIENT", command.OK)
+	command.HandleStore(t, st)
+	handleTx(t, st)
+
+	t.Handle("SET", func(c *command.Conn, args [][]byte) {
+		mode, get, err := parseSetOptions(args[3:])
+		if err != nil {
+			c.W.WriteError(err.Error())
 			return
+		}
+		old, existed, written := st.SetIf(string(args[1]), args[2], mode)
+		switch {
+		case get && existed:
+			c.W.WriteBulk(old)
+		case get, !written:
+			c.W.WriteNull()
+		default:
+			c.W.WriteSimple("OK")
+		}
+	})
+
+	t.Handle("GETVER", func(c *command.Conn, args [][]byte) {
+		if v, ver, ok := st.GetVersion(string(args[1])); ok {
+			c.W.WriteArray(2)
+			c.W.WriteBulk(v)
+			c.W.WriteInt(int64(ver))
+		} else {
+			c.W.WriteNull()
+		}
+	})
 
-		case "SET":
-			if len(parts) < 3 {
-				_ = writeLine(w, "-ERR usage: SET key value")
-				continue
-			}
-			key := parts[1]
-			// keep spaces in value
-			value := strings.TrimSpace(strings.TrimPrefix(line, parts[0]+" "+key))
-			st.Set(key, value)
-			_ = writeLine(w, "+OK")
-
-		case "GET":
-			if len(parts) != 2 {
-				_ = writeLine(w, "-ERR usage: GET key")
-				continue
-			}
-			key := parts[1]
-			if v, ok := st.Get(key); ok {
-				// simple bulk string: $<len>\n<value>
-				_ = writeLine(w, fmt.Sprintf("$%d", len(v)))
-				_ = writeLine(w, v)
-			} else {
-				_ = writeLine(w, "$-1")
-			}
+	t.Handle("CAS", func(c *command.Conn, args [][]byte) {
+		expected, err := strconv.ParseUint(string(args[2]), 10, 64)
+		if err != nil {
+			c.W.WriteError(kv.ErrNotInteger.Error())
+			return
+		}
+		if ver, ok := st.CompareAndSet(string(args[1]), expected, args[3]); ok {
+			c.W.WriteInt(int64(ver))
+		} else {
+			c.W.WriteNull()
+		}
+	})
 
-		case "DEL":
-			if len(parts) != 2 {
-				_ = writeLine(w, "-ERR usage: DEL key")
-				continue
-			}
-			key := parts[1]
-			if st.Del(key) {
-				_ = writeLine(w, ":1")
-			} else {
-				_ = writeLine(w, ":0")
-			}
+	t.Handle("INCR", func(c *command.Conn, args [][]byte) {
+		writeInt(c.W, st, string(args[1]), 1)
+	})
+	t.Handle("DECR", func(c *command.Conn, args [][]byte) {
+		writeInt(c.W, st, string(args[1]), -1)
+	})
 
-		case "KEYS":
-			keys := st.Keys()
-			_ = writeLine(w, fmt.Sprintf("*%d", len(keys)))
-			for _, k := range keys {
-				_ = writeLine(w, "+"+k)
+	incrBy := func(c *command.Conn, args [][]byte) {
+		delta, err := strconv.ParseInt(string(args[2]), 10, 64)
+		if err != nil {
+			c.W.WriteError(kv.ErrNotInteger.Error())
+			return
+		}
+		if strings.EqualFold(string(args[0]), "DECRBY") {
+			if delta == math.MinInt64 {
+				c.W.WriteError(kv.ErrOverflow.Error())
+				return
 			}
-
-		default:
-			_ = writeLine(w, "-ERR unknown command")
+			delta = -delta
 		}
+		writeInt(c.W, st, string(args[1]), delta)
 	}
+	t.Handle("INCRBY", incrBy)
+	t.Handle("DECRBY", incrBy)
+
+	t.Handle("INCRBYFLOAT", func(c *command.Conn, args [][]byte) {
+		delta, err := strconv.ParseFloat(string(args[2]), 64)
+		if err != nil || math.IsNaN(delta) || math.IsInf(delta, 0) {
+			c.W.WriteError(kv.ErrNotFloat.Error())
+			return
+		}
+		f, err := st.IncrByFloat(string(args[1]), delta)
+		if err != nil {
+			c.W.WriteError(err.Error())
+			return
+		}
+		// Redis replies with the new value as a bulk string, even in RESP3
+		c.W.WriteBulkString(strconv.FormatFloat(f, 'f', -1, 64))
+	})
+	return t
 }
 
 func main() {
-	addr := "127.0.0.1:6380"
-	st := NewStore()
+	addr := flag.String("addr", "127.0.0.1:6380", "RESP (TCP) listen address")
+	httpAddr := flag.String("http", "127.0.0.1:8080", "HTTP API listen address (empty to disable)")
+	grpcAddr := flag.String("grpc", "127.0.0.1:50051", "gRPC listen address (empty to disable)")
+	flag.BoolVar(&flushEach, "flush-each", false, "flush after every reply instead of once per batch (for benchmarks)")
+	flag.Parse()
+
+	// One store behind every protocol: a key SET over TCP is readable over
+	// HTTP and gRPC.
+	st := kv.NewStore()
+	st.StartActiveExpiry(100 * time.Millisecond)
+	defer st.Close()
+
+	if *httpAddr != "" {
+		go func() {
+			log.Printf("kv-server HTTP API listening on %s", *httpAddr)
+			log.Fatalf("http: %v", http.ListenAndServe(*httpAddr, httpapi.NewHandler(st)))
+		}()
+	}
+
+	if *grpcAddr != "" {
+		gln, err := net.Listen("tcp", *grpcAddr)
+		if err != nil {
+			log.Fatalf("grpc listen: %v", err)
+		}
+		go func() {
+			log.Printf("kv-server gRPC listening on %s", *grpcAddr)
+			log.Fatalf("grpc: %v", grpcapi.NewServer(st).Serve(gln))
+		}()
+	}
 
-	ln, err := net.Listen("tcp", addr)
+	cmds := newTable(st)
+	ln, err := net.Listen("tcp", *addr)
 	if err != nil {
 		log.Fatalf("listen: %v", err)
 	}
-	log.Printf("kv-server listening on %s", addr)
+	log.Printf("kv-server listening on %s", *addr)
 
 	for {
 		conn, err := ln.Accept()
@@ -158,8 +236,11 @@ func main() {
 			log.Printf("accept: %v", err)
 			continue
 		}
-		go handleConn(conn, st)
+		go handleConn(conn, cmds, st)
 	}
 
-	// Using netcat: nc 127.0.0.1:6380
+	// Using redis-cli: redis-cli -p 6380
+	// Using netcat (inline commands): nc 127.0.0.1 6380
+	// Using Go over gRPC: go run ./bytes/grpcapi/demo
+	// Using curl: curl -X PUT -H 'X-TTL: 60' --data-binary @file http://127.0.0.1:8080/keys/k
 }
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/command"
+	"github.com/vnscriptkid/sd-keyvalue-store/bytes/kv"
+)
+
+// txState is one connection's MULTI/EXEC/WATCH state, kept in
+// command.Conn.Data.
+type txState struct {
+	queuing bool
+	queued  [][][]byte  // commands replayed by EXEC
+	aborted bool        // a command was rejected while queuing
+	watch   *kv.Watcher // the WATCHed keys, nil if none
+}
+
+// dirty reports whether a watched key was written, deleted or expired
+// since WATCH. Any event means it was: versions alone can't tell, since a
+// key created and deleted again reads as missing both times.
+func (t *txState) dirty() bool {
+	if t.watch == nil {
+		return false
+	}
+	select {
+	case <-t.watch.C: // an event, or closed because they overflowed
+		return true
+	default:
+		return false
+	}
+}
+
+// handleTx serves the transaction commands. They are flagged NoMulti, so
+// they run even while the connection is queuing.
+func handleTx(t *command.Table, st *kv.Store) {
+	t.Handle("MULTI", func(c *command.Conn, args [][]byte) {
+		tx := c.Data.(*txState)
+		if tx.queuing {
+			c.W.WriteError("ERR MULTI calls can not be nested")
+			return
+		}
+		tx.queuing = true
+		c.W.WriteSimple("OK")
+	})
+
+	t.Handle("DISCARD", func(c *command.Conn, args [][]byte) {
+		tx := c.Data.(*txState)
+		if !tx.queuing {
+			c.W.WriteError("ERR DISCARD without MULTI")
+			return
+		}
+		tx.reset()
+		c.W.WriteSimple("OK")
+	})
+
+	t.Handle("WATCH", func(c *command.Conn, args [][]byte) {
+		tx := c.Data.(*txState)
+		if tx.queuing {
+			c.W.WriteError("ERR WATCH inside MULTI is not allowed")
+			return
+		}
+		if tx.watch == nil {
+			// One pending event is enough to know: a second one closes
+			// the watcher, which reads as dirty too.
+			tx.watch = st.Watch(1)
+		}
+		tx.watch.Add(command.KeyStrings(args[1:])...)
+		c.W.WriteSimple("OK")
+	})
+
+	t.Handle("UNWATCH", func(c *command.Conn, args [][]byte) {
+		c.Data.(*txState).unwatch()
+		c.W.WriteSimple("OK")
+	})
+
+	t.Handle("EXEC", func(c *command.Conn, args [][]byte) {
+		tx := c.Data.(*txState)
+		if !tx.queuing {
+			c.W.WriteError("ERR EXEC without MULTI")
+			return
+		}
+		queued, aborted := tx.queued, tx.aborted
+		defer tx.reset()
+		if aborted {
+			c.W.WriteError("EXECABORT Transaction discarded because of previous errors.")
+			return
+		}
+
+		// Replies go to the writer's buffer; the connection flushes them
+		// once the exclusive lock is released.
+		st.Atomic(func() {
+			if tx.dirty() {
+				c.W.WriteNullArray() // a watched key changed: abort
+				return
+			}
+			c.W.WriteArray(len(queued))
+			for _, args := range queued {
+				t.Dispatch(c, args)
+			}
+		})
+	})
+}
+
+// queue defers a command to EXEC. One that could never run fails the
+// whole transaction, like in Redis.
+func (t *txState) queue(c *command.Conn, cmds *command.Table, args [][]byte) {
+	if msg := cmds.Check(args); msg != "" {
+		t.aborted = true
+		c.W.WriteError(msg)
+		return
+	}
+	t.queued = append(t.queued, args)
+	c.W.WriteSimple("QUEUED")
+}
+
+// unwatch drops the watches.
+func (t *txState) unwatch() {
+	if t.watch != nil {
+		t.watch.Close()
+		t.watch = nil
+	}
+}
+
+// reset ends the transaction; EXEC and DISCARD also drop the watches.
+func (t *txState) reset() {
+	t.unwatch()
+	*t = txState{}
+}
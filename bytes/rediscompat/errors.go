@@ -0,0 +1,19 @@
+// Package rediscompat holds the Redis behaviour both stores in this repo
+// mimic (bytes/kv behind the servers and the eviction-policies store), so
+// they reply and expire alike without depending on each other.
+package rediscompat
+
+import "errors"
+
+// Error replies of the numeric commands, worded as Redis words them.
+var (
+	// ErrNotInteger is returned when INCR-style commands find a value that
+	// isn't a base-10 64-bit integer.
+	ErrNotInteger = errors.New("ERR value is not an integer or out of range")
+	// ErrNotFloat is returned by INCRBYFLOAT for a value that isn't a number.
+	ErrNotFloat = errors.New("ERR value is not a valid float")
+	// ErrOverflow is returned when an increment leaves the int64 range.
+	ErrOverflow = errors.New("ERR increment or decrement would overflow")
+	// ErrNaNOrInf is returned when a float increment produces NaN or ±Inf.
+	ErrNaNOrInf = errors.New("ERR increment would produce NaN or Infinity")
+)
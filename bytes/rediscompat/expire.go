@@ -0,0 +1,11 @@
+package rediscompat
+
+// Active expiry tuning, as in Redis activeExpireCycle: look at
+// ExpireSampleSize random keys with a TTL per round and go again while more
+// than ExpireRepeatPercent of the sample had expired, at most
+// ExpireMaxRounds rounds per cycle.
+const (
+	ExpireSampleSize    = 20
+	ExpireRepeatPercent = 25
+	ExpireMaxRounds     = 16
+)
@@ -0,0 +1,262 @@
+package resp
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Limits on what a peer may ask us to allocate, as in Redis.
+const (
+	maxBulkLen  = 512 << 20
+	maxElements = 1 << 20
+	maxInline   = 64 << 10
+)
+
+// Reader decodes RESP from a buffered stream.
+type Reader struct {
+	br *bufio.Reader
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{br: bufio.NewReader(r)}
+}
+
+// Buffered is the number of bytes already read from the connection but not
+// yet decoded: more pipelined commands are waiting when it is non-zero.
+func (r *Reader) Buffered() int { return r.br.Buffered() }
+
+// ReadCommand reads one command as its arguments. Clients send an array of
+// bulk strings, read here without recursion so no nesting can get in;
+// anything else is parsed as an inline command (a line of space-separated
+// words, with "double quotes" for words containing spaces), which keeps the
+// server usable from nc. An empty line returns no arguments.
+func (r *Reader) ReadCommand() ([][]byte, error) {
+	line, err := r.readLine()
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 || Type(line[0]) != Array {
+		return splitInline(line)
+	}
+
+	n, err := parseLen(line[1:], maxElements)
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("%w: expected an array of bulk strings", ErrProtocol)
+	}
+	// n is the peer's word: grow as arguments arrive rather than trust it.
+	args := make([][]byte, 0, min(n, 64))
+	for i := 0; i < n; i++ {
+		line, err := r.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if len(line) == 0 || Type(line[0]) != BulkString {
+			return nil, fmt.Errorf("%w: expected '$', got %q", ErrProtocol, line)
+		}
+		size, err := parseLen(line[1:], maxBulkLen)
+		if err != nil {
+			return nil, err
+		}
+		if size < 0 {
+			return nil, fmt.Errorf("%w: invalid bulk length", ErrProtocol)
+		}
+		arg, err := r.readBulk(size)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	return args, nil
+}
+
+// maxDepth bounds how deeply ReadValue lets aggregates nest, so a peer
+// can't exhaust the stack.
+const maxDepth = 128
+
+// ReadValue reads one value of any type.
+func (r *Reader) ReadValue() (Value, error) {
+	return r.readValue(0)
+}
+
+func (r *Reader) readValue(depth int) (Value, error) {
+	line, err := r.readLine()
+	if err != nil {
+		return Value{}, err
+	}
+	if len(line) == 0 {
+		return Value{}, fmt.Errorf("%w: empty line", ErrProtocol)
+	}
+
+	t, body := Type(line[0]), line[1:]
+	switch t {
+	case SimpleString, Error, BigNumber, Double:
+		return Value{Type: t, Str: body}, nil
+
+	case Integer:
+		n, err := strconv.ParseInt(string(body), 10, 64)
+		if err != nil {
+			return Value{}, fmt.Errorf("%w: invalid integer", ErrProtocol)
+		}
+		return Value{Type: t, Int: n}, nil
+
+	case Boolean:
+		switch string(body) {
+		case "t":
+			return Value{Type: t, Int: 1}, nil
+		case "f":
+			return Value{Type: t}, nil
+		}
+		return Value{}, fmt.Errorf("%w: invalid boolean", ErrProtocol)
+
+	case Null:
+		return Value{Type: Null}, nil
+
+	case BulkString, BulkError, Verbatim:
+		n, err := parseLen(body, maxBulkLen)
+		if err != nil {
+			return Value{}, err
+		}
+		if n < 0 {
+			return Value{Type: Null}, nil
+		}
+		b, err := r.readBulk(n)
+		if err != nil {
+			return Value{}, err
+		}
+		return Value{Type: t, Str: b}, nil
+
+	case Array, Set, Push, Map:
+		if depth >= maxDepth {
+			return Value{}, fmt.Errorf("%w: too deeply nested", ErrProtocol)
+		}
+		n, err := parseLen(body, maxElements)
+		if err != nil {
+			return Value{}, err
+		}
+		if n < 0 {
+			return Value{Type: Null}, nil
+		}
+		if t == Map {
+			n *= 2
+		}
+		elems := make([]Value, 0, min(n, 64))
+		for i := 0; i < n; i++ {
+			e, err := r.readValue(depth + 1)
+			if err != nil {
+				return Value{}, err
+			}
+			elems = append(elems, e)
+		}
+		return Value{Type: t, Elems: elems}, nil
+	}
+	return Value{}, fmt.Errorf("%w: unknown type '%c'", ErrProtocol, t)
+}
+
+// bulkChunk bounds what readBulk allocates before the payload arrives, so
+// a peer announcing a large bulk string and then stalling pins no more.
+const bulkChunk = 64 << 10
+
+// readBulk reads the n bytes of a bulk string and its CRLF. The buffer
+// grows as the bytes arrive rather than trusting n up front.
+func (r *Reader) readBulk(n int) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Grow(min(n+2, bulkChunk))
+	if got, err := io.CopyN(&buf, r.br, int64(n+2)); err != nil {
+		if err == io.EOF && got > 0 {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	b := buf.Bytes()
+	if b[n] != '\r' || b[n+1] != '\n' {
+		return nil, fmt.Errorf("%w: bulk string not terminated by CRLF", ErrProtocol)
+	}
+	return b[:n], nil
+}
+
+// readLine returns the next line without its \r\n (or bare \n).
+func (r *Reader) readLine() ([]byte, error) {
+	line, err := r.br.ReadSlice('\n')
+	if err == bufio.ErrBufferFull {
+		// Longer than the buffer: only inline commands get here legitimately.
+		buf := append([]byte(nil), line...)
+		for err == bufio.ErrBufferFull && len(buf) <= maxInline {
+			line, err = r.br.ReadSlice('\n')
+			buf = append(buf, line...)
+		}
+		if len(buf) > maxInline {
+			return nil, fmt.Errorf("%w: too big inline request", ErrProtocol)
+		}
+		line = buf
+	}
+	if err != nil {
+		return nil, err
+	}
+	line = line[:len(line)-1]
+	if n := len(line); n > 0 && line[n-1] == '\r' {
+		line = line[:n-1]
+	}
+	// ReadSlice's result is only valid until the next read.
+	return append([]byte(nil), line...), nil
+}
+
+func parseLen(b []byte, max int) (int, error) {
+	n, err := strconv.Atoi(string(b))
+	if err != nil || n < -1 || n > max {
+		return 0, fmt.Errorf("%w: invalid length", ErrProtocol)
+	}
+	return n, nil
+}
+
+// splitInline splits an inline command into words. Double-quoted words may
+// contain spaces and the escapes \" \\ \n \r \t.
+func splitInline(line []byte) ([][]byte, error) {
+	var args [][]byte
+	for i := 0; i < len(line); {
+		if line[i] == ' ' || line[i] == '\t' {
+			i++
+			continue
+		}
+		var word []byte
+		if line[i] != '"' {
+			for i < len(line) && line[i] != ' ' && line[i] != '\t' {
+				word = append(word, line[i])
+				i++
+			}
+			args = append(args, word)
+			continue
+		}
+
+		word = []byte{} // "" is an empty argument, not a missing one
+		for i++; ; i++ {
+			if i >= len(line) {
+				return nil, fmt.Errorf("%w: unbalanced quotes in request", ErrProtocol)
+			}
+			c := line[i]
+			if c == '"' {
+				i++
+				break
+			}
+			if c == '\\' && i+1 < len(line) {
+				i++
+				switch c = line[i]; c {
+				case 'n':
+					c = '\n'
+				case 'r':
+					c = '\r'
+				case 't':
+					c = '\t'
+				}
+			}
+			word = append(word, c)
+		}
+		args = append(args, word)
+	}
+	return args, nil
+}
@@ -0,0 +1,205 @@
+package resp
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestReadCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []string
+	}{
+		{"array", "*2\r\n$3\r\nGET\r\n$1\r\nk\r\n", []string{"GET", "k"}},
+		{"binary arg", "*2\r\n$4\r\nECHO\r\n$4\r\na\r\nb\r\n", []string{"ECHO", "a\r\nb"}},
+		{"empty arg", "*2\r\n$4\r\nECHO\r\n$0\r\n\r\n", []string{"ECHO", ""}},
+		{"empty array", "*0\r\n", []string{}},
+		{"inline", "SET k v\r\n", []string{"SET", "k", "v"}},
+		{"inline bare LF", "PING\n", []string{"PING"}},
+		{"inline quotes", `SET k "a b\"c\n"` + "\r\n", []string{"SET", "k", "a b\"c\n"}},
+		{"inline empty quotes", `ECHO ""` + "\r\n", []string{"ECHO", ""}},
+		{"empty line", "\r\n", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := NewReader(strings.NewReader(tt.in)).ReadCommand()
+			if err != nil {
+				t.Fatalf("ReadCommand: %v", err)
+			}
+			got := make([]string, len(args))
+			for i, a := range args {
+				got[i] = string(a)
+			}
+			if tt.want == nil {
+				if len(args) != 0 {
+					t.Fatalf("got %q, want no arguments", got)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadCommandPipelined(t *testing.T) {
+	r := NewReader(strings.NewReader("*1\r\n$4\r\nPING\r\nPING\r\n*2\r\n$3\r\nGET\r\n$1\r\nk\r\n"))
+	for _, want := range []string{"PING", "PING", "GET"} {
+		args, err := r.ReadCommand()
+		if err != nil {
+			t.Fatalf("ReadCommand: %v", err)
+		}
+		if string(args[0]) != want {
+			t.Fatalf("got %q, want %s", args[0], want)
+		}
+	}
+	if _, err := r.ReadCommand(); err != io.EOF {
+		t.Fatalf("got %v at the end, want io.EOF", err)
+	}
+}
+
+func TestReadCommandProtocolErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+	}{
+		{"nested array", "*1\r\n*1\r\n$1\r\na\r\n"},
+		{"integer arg", "*1\r\n:1\r\n"},
+		{"simple string arg", "*1\r\n+OK\r\n"},
+		{"empty arg line", "*1\r\n\r\n"},
+		{"bad array length", "*x\r\n"},
+		{"negative array length", "*-2\r\n"},
+		{"null array", "*-1\r\n"},
+		{"too many elements", "*9999999999\r\n"},
+		{"bad bulk length", "*1\r\n$x\r\n"},
+		{"null bulk", "*1\r\n$-1\r\n"},
+		{"bulk too long", "*1\r\n$999999999999\r\n"},
+		{"bulk missing CRLF", "*1\r\n$2\r\nabcd"},
+		{"bulk LF only", "*1\r\n$2\r\nab\n\n"},
+		{"unbalanced quotes", "SET k \"v\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewReader(strings.NewReader(tt.in)).ReadCommand()
+			if !errors.Is(err, ErrProtocol) {
+				t.Fatalf("got %v, want ErrProtocol", err)
+			}
+		})
+	}
+}
+
+func TestReadCommandTruncated(t *testing.T) {
+	for _, in := range []string{"*2\r\n$3\r\nGET\r\n", "*1\r\n$3\r\nGE"} {
+		_, err := NewReader(strings.NewReader(in)).ReadCommand()
+		if err != io.EOF && err != io.ErrUnexpectedEOF {
+			t.Fatalf("%q: got %v, want EOF", in, err)
+		}
+	}
+}
+
+// Deep nesting used to recurse until the stack overflowed, which no
+// recover can catch.
+func TestReadCommandLargeBulk(t *testing.T) {
+	big := strings.Repeat("x", 3*bulkChunk+5)
+	args, err := NewReader(strings.NewReader("*2\r\n$3\r\nSET\r\n$" + strconv.Itoa(len(big)) + "\r\n" + big + "\r\n")).ReadCommand()
+	if err != nil || len(args) != 2 || string(args[1]) != big {
+		t.Fatalf("got %d args, err %v", len(args), err)
+	}
+}
+
+// A peer announcing a huge bulk string and sending a few bytes must not
+// get the announced size allocated.
+func TestReadCommandStalledBulk(t *testing.T) {
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	_, err := NewReader(strings.NewReader("*1\r\n$536870911\r\nabc")).ReadCommand()
+	runtime.ReadMemStats(&after)
+	if err != io.ErrUnexpectedEOF {
+		t.Fatalf("got %v, want unexpected EOF", err)
+	}
+	if n := after.TotalAlloc - before.TotalAlloc; n > 4*bulkChunk {
+		t.Fatalf("allocated %d bytes for a 3-byte payload", n)
+	}
+}
+
+func TestReadCommandDeepNesting(t *testing.T) {
+	in := strings.Repeat("*1\r\n", 1_000_000)
+	_, err := NewReader(strings.NewReader(in)).ReadCommand()
+	if !errors.Is(err, ErrProtocol) {
+		t.Fatalf("got %v, want ErrProtocol", err)
+	}
+}
+
+func TestReadValueDepthLimit(t *testing.T) {
+	ok := strings.Repeat("*1\r\n", maxDepth) + ":1\r\n"
+	v, err := NewReader(strings.NewReader(ok)).ReadValue()
+	if err != nil {
+		t.Fatalf("%d levels: %v", maxDepth, err)
+	}
+	for i := 0; i < maxDepth; i++ {
+		v = v.Elems[0]
+	}
+	if v.Type != Integer || v.Int != 1 {
+		t.Fatalf("innermost value %v, want 1", v)
+	}
+
+	deep := strings.Repeat("*1\r\n", 1_000_000)
+	if _, err := NewReader(strings.NewReader(deep)).ReadValue(); !errors.Is(err, ErrProtocol) {
+		t.Fatalf("got %v, want ErrProtocol", err)
+	}
+}
+
+func TestReadValue(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Value
+	}{
+		{"+OK\r\n", Value{Type: SimpleString, Str: []byte("OK")}},
+		{"-ERR boom\r\n", Value{Type: Error, Str: []byte("ERR boom")}},
+		{":-42\r\n", Value{Type: Integer, Int: -42}},
+		{"$3\r\nabc\r\n", Value{Type: BulkString, Str: []byte("abc")}},
+		{"$-1\r\n", Value{Type: Null}},
+		{"*-1\r\n", Value{Type: Null}},
+		{"_\r\n", Value{Type: Null}},
+		{"#t\r\n", Value{Type: Boolean, Int: 1}},
+		{"*2\r\n:1\r\n$1\r\na\r\n", Value{Type: Array, Elems: []Value{
+			{Type: Integer, Int: 1}, {Type: BulkString, Str: []byte("a")},
+		}}},
+		{"%1\r\n+k\r\n:2\r\n", Value{Type: Map, Elems: []Value{
+			{Type: SimpleString, Str: []byte("k")}, {Type: Integer, Int: 2},
+		}}},
+	}
+	for _, tt := range tests {
+		got, err := NewReader(strings.NewReader(tt.in)).ReadValue()
+		if err != nil {
+			t.Fatalf("%q: %v", tt.in, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("%q: got %#v, want %#v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestReadValueProtocolErrors(t *testing.T) {
+	for _, in := range []string{
+		"\r\n",
+		"?x\r\n",
+		":12a\r\n",
+		"#x\r\n",
+		"$x\r\n",
+		"$-2\r\n",
+		"$2\r\nabcd",
+		"*1\r\n$1\r\nab\r\n",
+	} {
+		if _, err := NewReader(strings.NewReader(in)).ReadValue(); !errors.Is(err, ErrProtocol) {
+			t.Fatalf("%q: got %v, want ErrProtocol", in, err)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// Package resp implements the Redis serialization protocol, RESP2 and
+// RESP3: a Reader for commands and replies and a Writer that encodes replies
+// for whichever version the connection negotiated with HELLO.
+package resp
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Type is the leading byte of a RESP value.
+type Type byte
+
+const (
+	SimpleString Type = '+'
+	Error        Type = '-'
+	Integer      Type = ':'
+	BulkString   Type = '$'
+	Array        Type = '*'
+
+	// RESP3 only
+	Null      Type = '_'
+	Boolean   Type = '#'
+	Double    Type = ','
+	BigNumber Type = '('
+	BulkError Type = '!'
+	Verbatim  Type = '='
+	Map       Type = '%'
+	Set       Type = '~'
+	Push      Type = '>'
+)
+
+// ErrProtocol is returned for input that isn't valid RESP.
+var ErrProtocol = errors.New("ERR Protocol error")
+
+// Value is one decoded RESP value. RESP2's null bulk string ($-1) and null
+// array (*-1) decode as Null, like RESP3's _, so callers check one thing.
+type Value struct {
+	Type Type
+
+	// Str holds SimpleString, Error, BulkString, BulkError, Verbatim,
+	// BigNumber and the text of a Double.
+	Str []byte
+	// Int holds Integer, and Boolean as 0 or 1.
+	Int int64
+	// Elems holds Array, Set and Push; a Map is key, value, key, value, ...
+	Elems []Value
+}
+
+// IsNull reports whether v is a null of either protocol version.
+func (v Value) IsNull() bool { return v.Type == Null }
+
+// Err returns v as an error if it is an error reply, nil otherwise.
+func (v Value) Err() error {
+	if v.Type == Error || v.Type == BulkError {
+		return ReplyError(v.Str)
+	}
+	return nil
+}
+
+// String renders scalars as text and aggregates as a bracketed list, for
+// logs and demos.
+func (v Value) String() string {
+	switch v.Type {
+	case Integer:
+		return strconv.FormatInt(v.Int, 10)
+	case Boolean:
+		return strconv.FormatBool(v.Int != 0)
+	case Null:
+		return "(nil)"
+	case Array, Set, Push, Map:
+		items := make([]string, len(v.Elems))
+		for i, e := range v.Elems {
+			items[i] = e.String()
+		}
+		return "[" + strings.Join(items, " ") + "]"
+	default:
+		return string(v.Str)
+	}
+}
+
+// ReplyError is an error reply sent by the other side, e.g.
+// "WRONGTYPE Operation against a key holding the wrong kind of value".
+type ReplyError string
+
+func (e ReplyError) Error() string { return string(e) }
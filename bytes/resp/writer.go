@@ -0,0 +1,215 @@
+package resp
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"strconv"
+)
+
+// Writer encodes RESP into a buffer; nothing reaches the connection until
+// Flush. Types that RESP2 lacks are downgraded when the protocol is 2
+// (null -> $-1, map -> flat array, double -> bulk string, boolean -> integer).
+type Writer struct {
+	bw    *bufio.Writer
+	proto int
+	num   []byte // scratch for number formatting
+}
+
+// NewWriter returns a RESP2 writer; HELLO 3 switches it with SetProtocol.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{bw: bufio.NewWriter(w), proto: 2}
+}
+
+func (w *Writer) Protocol() int { return w.proto }
+
+func (w *Writer) SetProtocol(proto int) { w.proto = proto }
+
+// Flush sends everything written so far. Write errors are sticky and
+// reported here.
+func (w *Writer) Flush() error { return w.bw.Flush() }
+
+// Buffered is the number of bytes waiting for Flush.
+func (w *Writer) Buffered() int { return w.bw.Buffered() }
+
+func (w *Writer) WriteSimple(s string) {
+	w.bw.WriteByte(byte(SimpleString))
+	w.bw.WriteString(s)
+	w.crlf()
+}
+
+// WriteError writes an error reply. msg starts with the error code, e.g.
+// "ERR syntax error" or "WRONGTYPE ...".
+func (w *Writer) WriteError(msg string) {
+	w.bw.WriteByte(byte(Error))
+	w.bw.WriteString(msg)
+	w.crlf()
+}
+
+func (w *Writer) WriteInt(n int64) {
+	w.header(Integer, n)
+}
+
+func (w *Writer) WriteBulk(b []byte) {
+	w.header(BulkString, int64(len(b)))
+	w.bw.Write(b)
+	w.crlf()
+}
+
+func (w *Writer) WriteBulkString(s string) {
+	w.header(BulkString, int64(len(s)))
+	w.bw.WriteString(s)
+	w.crlf()
+}
+
+// WriteNull writes a missing value: $-1 in RESP2, _ in RESP3.
+func (w *Writer) WriteNull() {
+	if w.proto >= 3 {
+		w.bw.WriteByte(byte(Null))
+		w.crlf()
+		return
+	}
+	w.bw.WriteString("$-1\r\n")
+}
+
+// WriteNullArray writes a missing array (e.g. an aborted EXEC): *-1 in RESP2.
+func (w *Writer) WriteNullArray() {
+	if w.proto >= 3 {
+		w.WriteNull()
+		return
+	}
+	w.bw.WriteString("*-1\r\n")
+}
+
+// WriteArray starts an array of n elements; the caller writes them next.
+func (w *Writer) WriteArray(n int) {
+	w.header(Array, int64(n))
+}
+
+// WriteMap starts a map of n pairs; the caller writes key, value, ... next.
+func (w *Writer) WriteMap(n int) {
+	if w.proto >= 3 {
+		w.header(Map, int64(n))
+		return
+	}
+	w.header(Array, int64(2*n))
+}
+
+// WriteSet starts a set of n elements.
+func (w *Writer) WriteSet(n int) {
+	if w.proto >= 3 {
+		w.header(Set, int64(n))
+		return
+	}
+	w.header(Array, int64(n))
+}
+
+func (w *Writer) WriteDouble(f float64) {
+	var s string
+	switch {
+	case math.IsInf(f, 1):
+		s = "inf"
+	case math.IsInf(f, -1):
+		s = "-inf"
+	default:
+		s = strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	if w.proto >= 3 {
+		w.bw.WriteByte(byte(Double))
+		w.bw.WriteString(s)
+		w.crlf()
+		return
+	}
+	w.WriteBulkString(s)
+}
+
+func (w *Writer) WriteBool(b bool) {
+	if w.proto >= 3 {
+		if b {
+			w.bw.WriteString("#t\r\n")
+		} else {
+			w.bw.WriteString("#f\r\n")
+		}
+		return
+	}
+	if b {
+		w.WriteInt(1)
+	} else {
+		w.WriteInt(0)
+	}
+}
+
+// WriteValue re-encodes a decoded value, e.g. a reply relayed by a proxy.
+func (w *Writer) WriteValue(v Value) {
+	switch v.Type {
+	case SimpleString:
+		w.WriteSimple(string(v.Str))
+	case Error:
+		w.WriteError(string(v.Str))
+	case Integer:
+		w.WriteInt(v.Int)
+	case Null:
+		w.WriteNull()
+	case Boolean:
+		w.WriteBool(v.Int != 0)
+	case Array, Set, Push, Map:
+		t := v.Type
+		if w.proto < 3 {
+			t = Array
+		}
+		n := len(v.Elems)
+		if t == Map {
+			n /= 2
+		}
+		w.header(t, int64(n))
+		for _, e := range v.Elems {
+			w.WriteValue(e)
+		}
+	case BulkError:
+		if w.proto < 3 {
+			w.WriteError(string(v.Str))
+			return
+		}
+		w.header(BulkError, int64(len(v.Str)))
+		w.bw.Write(v.Str)
+		w.crlf()
+	case Double, BigNumber:
+		if w.proto < 3 {
+			w.WriteBulk(v.Str)
+			return
+		}
+		w.bw.WriteByte(byte(v.Type))
+		w.bw.Write(v.Str)
+		w.crlf()
+	case Verbatim:
+		// "txt:" or "mkd:" prefix, kept only where the type survives
+		if w.proto < 3 && len(v.Str) >= 4 {
+			w.WriteBulk(v.Str[4:])
+			return
+		}
+		w.header(Verbatim, int64(len(v.Str)))
+		w.bw.Write(v.Str)
+		w.crlf()
+	default:
+		w.WriteBulk(v.Str)
+	}
+}
+
+// WriteCommand writes a client command as an array of bulk strings.
+func (w *Writer) WriteCommand(args ...[]byte) {
+	w.WriteArray(len(args))
+	for _, a := range args {
+		w.WriteBulk(a)
+	}
+}
+
+func (w *Writer) header(t Type, n int64) {
+	w.bw.WriteByte(byte(t))
+	w.num = strconv.AppendInt(w.num[:0], n, 10)
+	w.bw.Write(w.num)
+	w.crlf()
+}
+
+func (w *Writer) crlf() {
+	w.bw.WriteString("\r\n")
+}